@@ -54,4 +54,5 @@ ALTER TABLE albums_by_artists
 ADD CONSTRAINT fk_albums_album_id FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
 ADD CONSTRAINT fk_albums_artist_id FOREIGN KEY (artist_id) REFERENCES artists(id) ON DELETE CASCADE,
 ALTER COLUMN album_id SET NOT NULL,
-ALTER COLUMN artist_id SET NOT NULL;
+ALTER COLUMN artist_id SET NOT NULL,
+ADD CONSTRAINT unique_album_artist UNIQUE (album_id, artist_id);
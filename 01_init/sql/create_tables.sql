@@ -5,7 +5,8 @@ CREATE TABLE IF NOT EXISTS artists (
     name VARCHAR(100),
     genre VARCHAR(50),
     country VARCHAR(100),
-    debut_year INT
+    debut_year INT,
+    metadata JSONB NOT NULL DEFAULT '{}'
 );
 
 CREATE TABLE IF NOT EXISTS albums (
@@ -13,7 +14,8 @@ CREATE TABLE IF NOT EXISTS albums (
     title VARCHAR(100),
     release_date DATE,
     label VARCHAR(100),
-    genre VARCHAR(50)
+    genre VARCHAR(50),
+    metadata JSONB NOT NULL DEFAULT '{}'
 );
 
 CREATE TABLE IF NOT EXISTS tracks (
@@ -24,7 +26,8 @@ CREATE TABLE IF NOT EXISTS tracks (
     explicit BOOLEAN,
     duration INT,
     genre VARCHAR(50),
-    stream_count BIGINT
+    stream_count BIGINT,
+    metadata JSONB NOT NULL DEFAULT '{}'
 );
 
 CREATE TABLE IF NOT EXISTS playlists (
@@ -33,7 +36,9 @@ CREATE TABLE IF NOT EXISTS playlists (
     description TEXT,
     private BOOLEAN,
     last_updated TIMESTAMP,
-    rating INT
+    rating INT,
+    version BIGINT NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP WITH TIME ZONE
 );
 
 CREATE TABLE IF NOT EXISTS users (
@@ -42,7 +47,9 @@ CREATE TABLE IF NOT EXISTS users (
     registration_date TIMESTAMP WITH TIME ZONE,
     birth_date DATE,
     premium BOOLEAN,
-    premium_expiration TIMESTAMP WITH TIME ZONE
+    premium_expiration TIMESTAMP WITH TIME ZONE,
+    version BIGINT NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP WITH TIME ZONE
 );
 
 CREATE TABLE IF NOT EXISTS playlist_tracks (
@@ -68,3 +75,43 @@ CREATE TABLE IF NOT EXISTS albums_by_artists (
     album_id UUID,
     artist_id UUID
 );
+
+-- Журнал массовых изменений, выполняемых приложениями (06_cli_app, 07_gorm)
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    db_user VARCHAR(100) NOT NULL DEFAULT session_user,
+    db_role VARCHAR(100) NOT NULL DEFAULT current_user,
+    performed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT clock_timestamp(),
+    operation VARCHAR(100) NOT NULL,
+    details TEXT,
+    rows_affected BIGINT NOT NULL
+);
+
+-- История изменений пользователей и плейлистов (07_gorm): значение поля до и после
+CREATE TABLE IF NOT EXISTS edit_history (
+    id BIGSERIAL PRIMARY KEY,
+    entity VARCHAR(50) NOT NULL,
+    record_id UUID NOT NULL,
+    field VARCHAR(100) NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    version BIGINT NOT NULL,
+    edited_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT clock_timestamp(),
+    db_user VARCHAR(100) NOT NULL DEFAULT session_user
+);
+
+CREATE INDEX IF NOT EXISTS idx_edit_history_record ON edit_history (entity, record_id, edited_at);
+
+-- Ревью на альбомы и треки (06_cli_app): не более одного ревью пользователя на объект
+CREATE TABLE IF NOT EXISTS reviews (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    album_id UUID REFERENCES albums(id) ON DELETE CASCADE,
+    track_id UUID REFERENCES tracks(id) ON DELETE CASCADE,
+    rating INT CHECK (rating >= 1 AND rating <= 10),
+    comment TEXT,
+    review_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT check_review_target CHECK (num_nonnulls(album_id, track_id) = 1),
+    CONSTRAINT unique_user_album_review UNIQUE (user_id, album_id),
+    CONSTRAINT unique_user_track_review UNIQUE (user_id, track_id)
+);
@@ -15,3 +15,9 @@ DROP TABLE IF EXISTS tracks CASCADE;
 DROP TABLE IF EXISTS albums CASCADE;
 
 DROP TABLE IF EXISTS artists CASCADE;
+
+DROP TABLE IF EXISTS audit_log CASCADE;
+
+DROP TABLE IF EXISTS edit_history CASCADE;
+
+DROP TABLE IF EXISTS reviews CASCADE;
@@ -21,6 +21,11 @@ type PostgresConfig struct {
 	Host     string `env:"POSTGRES_HOST"`
 	Port     string `env:"POSTGRES_PORT"`
 	SSLMode  string `env:"POSTGRES_SSL_MODE"`
+
+	// Роли, на которые можно переключиться через SET ROLE (пользователь должен быть их членом)
+	Roles []string `env:"POSTGRES_ROLES" env-separator:","`
+	// Роль, устанавливаемая при подключении (пустая - роль пользователя POSTGRES_USER)
+	Role string `env:"POSTGRES_ROLE"`
 }
 
 func MustLoad() *Config {
@@ -1,7 +1,6 @@
 package controller
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
@@ -11,15 +10,18 @@ import (
 
 	"log/slog"
 
-	"github.com/google/uuid"
 	"github.com/hahaclassic/databases/06_cli_app/internal/models"
 	"github.com/hahaclassic/databases/06_cli_app/internal/storage"
+	"github.com/hahaclassic/databases/pkg/pager"
 	"github.com/jedib0t/go-pretty/table"
 )
 
 type Contoller struct {
 	// c.storage c.storage.MusicService
 	storage storage.MusicServiceStorage
+
+	// allowed - операции, доступные текущей роли (nil - все операции)
+	allowed map[Operation]bool
 }
 
 func NewController(storage storage.MusicServiceStorage) *Contoller {
@@ -37,47 +39,61 @@ func (c *Contoller) Start(ctx context.Context) {
 		ArtistAlbums:             c.artistAlbums,
 		FreePremiumForPensioners: c.freePremiumForPensioners,
 		CurrentUser:              c.currentUser,
-		CreateReviewsTable:       c.createReviewsTable,
 		AddReview:                c.addReview,
+		Reviews:                  c.reviews,
+		UserReviews:              c.userReviews,
+		UpdateReview:             c.updateReview,
+		DeleteReview:             c.deleteReview,
+		RatingSummary:            c.ratingSummary,
+		SwitchRole:               c.switchRole,
 	}
 
+	c.refreshPrivileges(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		default:
-			menu()
+			c.menu()
 			fmt.Print("Enter operation number (or 0 for exit): ")
 			var input string
-			_, err := fmt.Scanln(&input)
+			_, err := fmt.Fscanln(stdin, &input)
 			if err != nil {
 				fmt.Println("Invalid operation number. Try again.")
 			}
 
 			operation, err := strconv.Atoi(input)
-			if err != nil || operation < 0 || operation > int(AddReview) {
+			if err != nil || operation < int(Exit) || operation >= int(operationsEnd) {
 				fmt.Println("Invalid data. Try again.")
 				continue
 			}
 
-			if operation == 0 {
+			if operation == int(Exit) {
 				fmt.Println("Exit")
 				return
 			}
 
+			if !c.isAllowed(Operation(operation)) {
+				fmt.Printf("Operation %s is not permitted for the current role.\n", Operation(operation))
+				continue
+			}
+
 			methods[Operation(operation)](ctx)
 		}
 	}
 }
 
-func menu() {
+func (c *Contoller) menu() {
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
 	t.AppendHeader(table.Row{"#", "Operation"})
-	for i := CountArtists; i <= AddReview; i++ {
-		t.AppendRow(table.Row{int(i), i.String()})
+	for i := operationsStart + 1; i < operationsEnd; i++ {
+		if c.isAllowed(i) {
+			t.AppendRow(table.Row{int(i), i.String()})
+		}
 	}
-	t.AppendRow(table.Row{0, "Exit"})
+	t.AppendRow(table.Row{int(Exit), Exit.String()})
 	t.Render()
 }
 
@@ -91,62 +107,42 @@ func (app *Contoller) countArtists(ctx context.Context) {
 }
 
 func (app *Contoller) tracksAdditionalInfo(ctx context.Context) {
-	var limit int
-	fmt.Print("Enter limit for tracks: ")
-	_, err := fmt.Scan(&limit)
-	if err != nil {
-		slog.Error("[ERR]", "err", err)
-		return
-	}
-
-	tracks, err := app.storage.TracksAdditionalInfo(ctx, limit)
-	if err != nil {
-		log.Printf("Error getting tracks info: %v", err)
-		return
-	}
-
-	if len(tracks) == 0 {
-		fmt.Println("No additional track info found.")
-		return
-	}
-
-	headers := []string{"Track Name", "Artist", "Album", "ReleaseDate", "StreamCount"}
-	rows := [][]interface{}{}
-	for _, track := range tracks {
-		rows = append(rows, []interface{}{track.Name, track.Artist, track.Album,
-			track.ReleaseDate, track.StreamCount})
-	}
-
-	printTable(headers, rows)
+	p := &pager.Pager[*models.TracksAdditionalInfo]{
+		Headers: []string{"Track Name", "Artist", "Album", "ReleaseDate", "StreamCount"},
+		SortColumns: []string{models.SortByStreamCount, models.SortByName, models.SortByArtist,
+			models.SortByAlbum, models.SortByReleaseDate},
+		Desc:  true,
+		Fetch: app.storage.TracksAdditionalInfo,
+		Row: func(track *models.TracksAdditionalInfo) []interface{} {
+			return []interface{}{track.Name, track.Artist, track.Album,
+				track.ReleaseDate, track.StreamCount}
+		},
+	}
+
+	runPager(ctx, p)
 }
 
 func (app *Contoller) bestTracks(ctx context.Context) {
 	var limit int
 	fmt.Print("Enter limit for best tracks: ")
-	_, err := fmt.Scan(&limit)
+	_, err := fmt.Fscanln(stdin, &limit)
 	if err != nil {
 		slog.Error("[ERR]", "err", err)
 		return
 	}
 
-	tracks, err := app.storage.BestTracks(ctx, limit)
-	if err != nil {
-		log.Printf("Error getting best tracks: %v", err)
-		return
-	}
-
-	if len(tracks) == 0 {
-		fmt.Println("No tracks found.")
-		return
-	}
-
-	headers := []string{"Track Name", "Stream Count", "Rank"}
-	rows := [][]interface{}{}
-	for _, track := range tracks {
-		rows = append(rows, []interface{}{track.Name, track.StreamCount, track.Rank})
+	p := &pager.Pager[*models.RankedTrack]{
+		Headers:     []string{"Track Name", "Stream Count", "Rank"},
+		SortColumns: []string{models.SortByRank, models.SortByName, models.SortByStreamCount},
+		Fetch: func(ctx context.Context, q *pager.Query) (*pager.Page[*models.RankedTrack], error) {
+			return app.storage.BestTracks(ctx, limit, q)
+		},
+		Row: func(track *models.RankedTrack) []interface{} {
+			return []interface{}{track.Name, track.StreamCount, track.Rank}
+		},
 	}
 
-	printTable(headers, rows)
+	runPager(ctx, p)
 }
 
 func (app *Contoller) tablesNames(ctx context.Context) {
@@ -171,21 +167,15 @@ func (app *Contoller) tablesNames(ctx context.Context) {
 }
 
 func (app *Contoller) countArtistTracks(ctx context.Context) {
-	var artistID string
-	fmt.Print("Enter artist ID (UUID): ")
-	_, err := fmt.Scan(&artistID)
-	if err != nil {
-		slog.Error("[ERR]", "err", err)
+	artistID, err := app.pick(ctx, models.ArtistSearch)
+	if errors.Is(err, errPickCancelled) {
 		return
-	}
-
-	parsedID, err := uuid.Parse(artistID)
-	if err != nil {
-		log.Printf("Error parsing artist ID: %v", err)
+	} else if err != nil {
+		slog.Error("[ERR]", "err", err)
 		return
 	}
 
-	count, err := app.storage.CountArtistTracks(ctx, parsedID)
+	count, err := app.storage.CountArtistTracks(ctx, artistID)
 	if err != nil {
 		log.Printf("Error getting artist tracks count: %v", err)
 		return
@@ -194,21 +184,15 @@ func (app *Contoller) countArtistTracks(ctx context.Context) {
 }
 
 func (app *Contoller) artistAlbums(ctx context.Context) {
-	var artistID string
-	fmt.Print("Enter artist ID (UUID): ")
-	_, err := fmt.Scan(&artistID)
-	if err != nil {
-		slog.Error("[ERR]", "err", err)
+	artistID, err := app.pick(ctx, models.ArtistSearch)
+	if errors.Is(err, errPickCancelled) {
 		return
-	}
-
-	parsedID, err := uuid.Parse(artistID)
-	if err != nil {
-		log.Printf("Error parsing artist ID: %v", err)
+	} else if err != nil {
+		slog.Error("[ERR]", "err", err)
 		return
 	}
 
-	albums, err := app.storage.ArtistAlbums(ctx, parsedID)
+	albums, err := app.storage.ArtistAlbums(ctx, artistID)
 	if err != nil {
 		log.Printf("Error getting albums for artist: %v", err)
 		return
@@ -229,93 +213,31 @@ func (app *Contoller) artistAlbums(ctx context.Context) {
 }
 
 func (app *Contoller) freePremiumForPensioners(ctx context.Context) {
-	err := app.storage.FreePremiumForPensioners(ctx)
-	if err != nil {
-		log.Printf("Error granting free premium: %v", err)
-		return
-	}
-	fmt.Println("Free premium granted to pensioners.")
-}
-
-func (app *Contoller) currentUser(ctx context.Context) {
-	user, err := app.storage.CurrentUser(ctx)
+	preview, err := app.storage.PensionersPreview(ctx)
 	if err != nil {
-		log.Printf("Error getting current user: %v", err)
-		return
-	}
-	fmt.Printf("Current user: %s\n", user)
-}
-
-func (app *Contoller) createReviewsTable(ctx context.Context) {
-	err := app.storage.CreateReviewsTable(ctx)
-	if errors.Is(err, storage.ErrTableAlreadyExists) {
-		log.Print("Table already exists.")
-		return
-	} else if err != nil {
-		log.Printf("Error creating reviews table: %v", err)
+		log.Printf("Error previewing free premium: %v", err)
 		return
 	}
 
-	fmt.Println("Reviews table created.")
-}
-
-func (app *Contoller) addReview(ctx context.Context) {
-	id, err := uuid.NewRandom()
-	if err != nil {
-		slog.Error("[ERR]", "err", err)
+	if !confirmMutation(preview) {
 		return
 	}
-	review := &models.Review{ID: id}
 
-	var strUUID string
-	fmt.Print("Enter review user ID (UUID): ")
-	_, err = fmt.Scan(&strUUID)
-	if err != nil {
-		slog.Error("[ERR]", "err", err)
-		return
-	}
-	parsedUUID, err := uuid.Parse(strUUID)
+	affected, err := app.storage.FreePremiumForPensioners(ctx)
 	if err != nil {
-		slog.Error("[ERR]", "err", err)
-		return
-	}
-	review.UserID = parsedUUID
-
-	fmt.Print("Enter album ID (UUID): ")
-	_, err = fmt.Scan(&strUUID)
-	if err != nil {
-		slog.Error("[ERR]", "err", err)
-		return
-	}
-	parsedUUID, err = uuid.Parse(strUUID)
-	if err != nil {
-		slog.Error("[ERR]", "err", err)
-		return
-	}
-	review.AlbumID = parsedUUID
-
-	fmt.Print("Enter rating (1-10): ")
-	_, err = fmt.Scan(&review.Rating)
-	if err != nil {
-		slog.Error("[ERR]", "err", err)
+		log.Printf("Error granting free premium: %v", err)
 		return
 	}
+	fmt.Printf("Free premium granted to %d pensioners.\n", affected)
+}
 
-	scanner := bufio.NewScanner(os.Stdin)
-	fmt.Print("Enter comment: ")
-	if scanner.Scan() {
-		review.Comment = scanner.Text()
-	}
-	if err := scanner.Err(); err != nil {
-		fmt.Println("Error reading input:", err)
-	}
-
-	err = app.storage.AddReview(ctx, review)
+func (app *Contoller) currentUser(ctx context.Context) {
+	user, err := app.storage.CurrentUser(ctx)
 	if err != nil {
-		log.Printf("Error adding review: %v", err)
+		log.Printf("Error getting current user: %v", err)
 		return
 	}
-	fmt.Println("Review added successfully!")
+	fmt.Printf("Current user: %s\n", user)
 }
 
 func printTable(headers []string, rows [][]interface{}) {
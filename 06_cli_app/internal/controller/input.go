@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// stdin - общий буферизованный ввод консоли. Все чтения идут через него:
+// отдельный буфер на каждый вызов терял бы уже прочитанные из os.Stdin данные.
+var stdin = bufio.NewReader(os.Stdin)
+
+func readUUID(prompt string) (uuid.UUID, error) {
+	var strUUID string
+	fmt.Print(prompt)
+	if _, err := fmt.Fscanln(stdin, &strUUID); err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.Parse(strUUID)
+}
+
+// readLine читает строку целиком (в отличие от fmt.Scan, допускает пробелы)
+func readLine(prompt string) (string, error) {
+	fmt.Print(prompt)
+
+	line, err := stdin.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", err
+	}
+
+	return strings.TrimSpace(line), nil
+}
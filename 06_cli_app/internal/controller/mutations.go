@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hahaclassic/databases/06_cli_app/internal/models"
+)
+
+// confirmMutation показывает предпросмотр массового изменения и запрашивает
+// подтверждение. Изменение выполняется только если возвращено true.
+func confirmMutation(preview *models.MutationPreview) bool {
+	fmt.Printf("%s will affect %d rows.\n", preview.Operation, preview.RowsAffected)
+	if len(preview.Sample) > 0 {
+		fmt.Printf("Sample (%d of %d):\n", len(preview.Sample), preview.RowsAffected)
+		printTable(preview.Headers, preview.Sample)
+	}
+
+	answer, err := readLine("Apply changes? [y/N]: ")
+	if err != nil || !strings.EqualFold(answer, "y") {
+		fmt.Println("Changes discarded.")
+		return false
+	}
+
+	return true
+}
@@ -3,7 +3,9 @@ package controller
 type Operation int
 
 const (
-	CountArtists Operation = iota + 1
+	operationsStart Operation = iota
+
+	CountArtists
 	TracksAdditionalInfo
 	BestTracks
 	TablesNames
@@ -11,8 +13,18 @@ const (
 	ArtistAlbums
 	FreePremiumForPensioners
 	CurrentUser
-	CreateReviewsTable
 	AddReview
+
+	Reviews
+	UserReviews
+	UpdateReview
+	DeleteReview
+	RatingSummary
+
+	SwitchRole
+
+	operationsEnd
+	Exit Operation = 0
 )
 
 func (op Operation) String() string {
@@ -33,10 +45,22 @@ func (op Operation) String() string {
 		return "FreePremiumForPensioners"
 	case CurrentUser:
 		return "CurrentUser"
-	case CreateReviewsTable:
-		return "CreateReviewsTable"
 	case AddReview:
 		return "AddReview"
+	case Reviews:
+		return "Reviews"
+	case UserReviews:
+		return "UserReviews"
+	case UpdateReview:
+		return "UpdateReview"
+	case DeleteReview:
+		return "DeleteReview"
+	case RatingSummary:
+		return "RatingSummary"
+	case SwitchRole:
+		return "SwitchRole"
+	case Exit:
+		return "Exit"
 	default:
 		return "UnknownOperation"
 	}
@@ -0,0 +1,13 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/hahaclassic/databases/pkg/pager"
+)
+
+// runPager запускает постраничный просмотр с выводом и вводом консоли контроллера
+func runPager[T any](ctx context.Context, p *pager.Pager[T]) {
+	p.Print, p.ReadLine = printTable, readLine
+	p.Run(ctx)
+}
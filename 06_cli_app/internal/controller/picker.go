@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/06_cli_app/internal/models"
+)
+
+const searchLimit = 10
+
+var errPickCancelled = errors.New("selection cancelled")
+
+// pick - интерактивный выбор исполнителя, альбома или трека по части названия.
+// Вместо названия можно сразу ввести UUID, пустая строка отменяет выбор.
+func (app *Contoller) pick(ctx context.Context, kind models.SearchKind) (uuid.UUID, error) {
+	for {
+		query, err := readLine(fmt.Sprintf("Search %s by name or UUID (empty to cancel): ", kind))
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if query == "" {
+			return uuid.Nil, errPickCancelled
+		}
+
+		if id, err := uuid.Parse(query); err == nil {
+			return id, nil
+		}
+
+		results, err := app.storage.Search(ctx, kind, query, searchLimit)
+		if err != nil {
+			return uuid.Nil, err
+		}
+
+		if len(results) == 0 {
+			fmt.Printf("No %ss found. Try again.\n", kind)
+			continue
+		}
+
+		headers := []string{"#", "Name", "Details", "ID"}
+		rows := [][]interface{}{}
+		for i, r := range results {
+			rows = append(rows, []interface{}{i + 1, r.Name, r.Details, r.ID})
+		}
+		printTable(headers, rows)
+
+		var choice int
+		fmt.Printf("Select %s # (0 to search again): ", kind)
+		if _, err := fmt.Fscanln(stdin, &choice); err != nil || choice < 0 || choice > len(results) {
+			fmt.Println("Invalid number. Try again.")
+			continue
+		}
+		if choice == 0 {
+			continue
+		}
+
+		selected := results[choice-1]
+		fmt.Printf("Selected %s: %s\n", kind, selected.Name)
+
+		return selected.ID, nil
+	}
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strconv"
+
+	"github.com/hahaclassic/databases/06_cli_app/internal/models"
+)
+
+const loginRole = "(login role)"
+
+// requiredPrivileges - права, необходимые текущей роли для выполнения операции
+var requiredPrivileges = map[Operation][]models.Privilege{
+	CountArtists: {models.TablePrivilege("artists", "SELECT")},
+	TracksAdditionalInfo: {
+		models.TablePrivilege("tracks", "SELECT"),
+		models.TablePrivilege("albums", "SELECT"),
+		models.TablePrivilege("albums_by_artists", "SELECT"),
+		models.TablePrivilege("artists", "SELECT"),
+	},
+	BestTracks: {models.TablePrivilege("tracks", "SELECT")},
+	CountArtistTracks: {
+		models.TablePrivilege("artists", "SELECT"),
+		models.TablePrivilege("tracks_by_artists", "SELECT"),
+	},
+	ArtistAlbums: {
+		models.TablePrivilege("artists", "SELECT"),
+		models.TablePrivilege("albums", "SELECT"),
+		models.TablePrivilege("albums_by_artists", "SELECT"),
+	},
+	FreePremiumForPensioners: {
+		models.TablePrivilege("users", "SELECT"),
+		models.TablePrivilege("users", "UPDATE"),
+		models.TablePrivilege("audit_log", "INSERT"),
+	},
+	AddReview: {
+		models.TablePrivilege("reviews", "INSERT"),
+		models.TablePrivilege("albums", "SELECT"),
+		models.TablePrivilege("tracks", "SELECT"),
+	},
+	Reviews:       {models.TablePrivilege("reviews", "SELECT")},
+	UserReviews:   {models.TablePrivilege("reviews", "SELECT")},
+	UpdateReview:  {models.TablePrivilege("reviews", "UPDATE")},
+	DeleteReview:  {models.TablePrivilege("reviews", "DELETE")},
+	RatingSummary: {models.TablePrivilege("reviews", "SELECT")},
+}
+
+func (c *Contoller) isAllowed(op Operation) bool {
+	return c.allowed == nil || c.allowed[op]
+}
+
+// refreshPrivileges пересчитывает доступные операции для текущей роли
+func (c *Contoller) refreshPrivileges(ctx context.Context) {
+	var (
+		ops        []Operation
+		privileges []models.Privilege
+	)
+	for op, required := range requiredPrivileges {
+		for _, p := range required {
+			ops = append(ops, op)
+			privileges = append(privileges, p)
+		}
+	}
+
+	granted, err := c.storage.CheckPrivileges(ctx, privileges)
+	if err != nil {
+		// при ошибке меню не ограничивается: права все равно проверит сервер
+		slog.Error("[ERR]", "err", err)
+		c.allowed = nil
+		return
+	}
+
+	allowed := make(map[Operation]bool, operationsEnd)
+	for op := operationsStart + 1; op < operationsEnd; op++ {
+		allowed[op] = true
+	}
+	for i, ok := range granted {
+		if !ok {
+			allowed[ops[i]] = false
+		}
+	}
+
+	c.allowed = allowed
+}
+
+func (app *Contoller) switchRole(ctx context.Context) {
+	roles, err := app.storage.Roles(ctx)
+	if err != nil {
+		log.Printf("Error getting roles: %v", err)
+		return
+	}
+	roles = append([]string{""}, roles...)
+
+	headers := []string{"#", "Role"}
+	rows := [][]interface{}{}
+	for i, role := range roles {
+		if role == "" {
+			role = loginRole
+		}
+		rows = append(rows, []interface{}{i, role})
+	}
+	printTable(headers, rows)
+
+	input, err := readLine("Select role #: ")
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	i, err := strconv.Atoi(input)
+	if err != nil || i < 0 || i >= len(roles) {
+		fmt.Println("Invalid number.")
+		return
+	}
+
+	if err := app.storage.SetRole(ctx, roles[i]); err != nil {
+		log.Printf("Error switching role: %v", err)
+		return
+	}
+
+	app.refreshPrivileges(ctx)
+
+	user, err := app.storage.CurrentUser(ctx)
+	if err != nil {
+		log.Printf("Error getting current user: %v", err)
+		return
+	}
+	fmt.Printf("Current role: %s\n", user)
+}
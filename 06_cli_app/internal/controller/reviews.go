@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/06_cli_app/internal/models"
+	"github.com/hahaclassic/databases/06_cli_app/internal/storage"
+)
+
+func (app *Contoller) addReview(ctx context.Context) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+	review := &models.Review{ID: id}
+
+	if review.UserID, err = readUUID("Enter review user ID (UUID): "); err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	review.Target, review.TargetID, err = app.readReviewTarget(ctx)
+	if errors.Is(err, errPickCancelled) {
+		return
+	} else if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	if review.Rating, review.Comment, err = readRatingAndComment(); err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	err = app.storage.AddReview(ctx, review)
+	if errors.Is(err, storage.ErrAlreadyReviewed) {
+		fmt.Printf("User has already reviewed this %s. Use UpdateReview instead.\n", review.Target)
+		return
+	} else if err != nil {
+		log.Printf("Error adding review: %v", err)
+		return
+	}
+	fmt.Println("Review added successfully!")
+}
+
+func (app *Contoller) reviews(ctx context.Context) {
+	target, targetID, err := app.readReviewTarget(ctx)
+	if errors.Is(err, errPickCancelled) {
+		return
+	} else if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	reviews, err := app.storage.Reviews(ctx, target, targetID)
+	if err != nil {
+		log.Printf("Error getting reviews: %v", err)
+		return
+	}
+
+	printReviews(reviews)
+}
+
+func (app *Contoller) userReviews(ctx context.Context) {
+	userID, err := readUUID("Enter user ID (UUID): ")
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	reviews, err := app.storage.UserReviews(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting user reviews: %v", err)
+		return
+	}
+
+	printReviews(reviews)
+}
+
+func (app *Contoller) updateReview(ctx context.Context) {
+	reviewID, err := readUUID("Enter review ID (UUID): ")
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+	review := &models.Review{ID: reviewID}
+
+	if review.Rating, review.Comment, err = readRatingAndComment(); err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	err = app.storage.UpdateReview(ctx, review)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("Review not found.")
+		return
+	} else if err != nil {
+		log.Printf("Error updating review: %v", err)
+		return
+	}
+	fmt.Println("Review updated successfully!")
+}
+
+func (app *Contoller) deleteReview(ctx context.Context) {
+	reviewID, err := readUUID("Enter review ID (UUID): ")
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	err = app.storage.DeleteReview(ctx, reviewID)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("Review not found.")
+		return
+	} else if err != nil {
+		log.Printf("Error deleting review: %v", err)
+		return
+	}
+	fmt.Println("Review deleted successfully!")
+}
+
+func (app *Contoller) ratingSummary(ctx context.Context) {
+	target, targetID, err := app.readReviewTarget(ctx)
+	if errors.Is(err, errPickCancelled) {
+		return
+	} else if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	summary, err := app.storage.RatingSummary(ctx, target, targetID)
+	if err != nil {
+		log.Printf("Error getting rating summary: %v", err)
+		return
+	}
+
+	if summary.Count == 0 {
+		fmt.Printf("No reviews found for this %s.\n", target)
+		return
+	}
+
+	fmt.Printf("Average rating: %.2f (%d reviews)\n", summary.Average, summary.Count)
+
+	headers := []string{"Rating", "Count", ""}
+	rows := [][]interface{}{}
+	for i, count := range summary.Histogram {
+		bar := strings.Repeat("#", count*40/summary.Count)
+		rows = append(rows, []interface{}{i + models.MinRating, count, bar})
+	}
+
+	printTable(headers, rows)
+}
+
+func printReviews(reviews []*models.Review) {
+	if len(reviews) == 0 {
+		fmt.Println("No reviews found.")
+		return
+	}
+
+	headers := []string{"Review ID", "User ID", "Target", "Target ID", "Rating", "Comment", "Date"}
+	rows := [][]interface{}{}
+	for _, r := range reviews {
+		rows = append(rows, []interface{}{r.ID, r.UserID, r.Target, r.TargetID,
+			r.Rating, r.Comment, r.ReviewDate.Format("2006-01-02 15:04")})
+	}
+
+	printTable(headers, rows)
+}
+
+func (app *Contoller) readReviewTarget(ctx context.Context) (models.ReviewTarget, uuid.UUID, error) {
+	var target models.ReviewTarget
+	fmt.Printf("Review target (%d - %s, %d - %s): ", models.AlbumReview, models.AlbumReview,
+		models.TrackReview, models.TrackReview)
+	if _, err := fmt.Fscanln(stdin, &target); err != nil {
+		return 0, uuid.Nil, err
+	}
+
+	kind := models.AlbumSearch
+	switch target {
+	case models.AlbumReview:
+	case models.TrackReview:
+		kind = models.TrackSearch
+	default:
+		return 0, uuid.Nil, fmt.Errorf("invalid review target: %d", target)
+	}
+
+	targetID, err := app.pick(ctx, kind)
+	if err != nil {
+		return 0, uuid.Nil, err
+	}
+
+	return target, targetID, nil
+}
+
+func readRatingAndComment() (rating int, comment string, err error) {
+	fmt.Printf("Enter rating (%d-%d): ", models.MinRating, models.MaxRating)
+	if _, err = fmt.Fscanln(stdin, &rating); err != nil {
+		return 0, "", err
+	}
+
+	if rating < models.MinRating || rating > models.MaxRating {
+		return 0, "", fmt.Errorf("rating must be between %d and %d", models.MinRating, models.MaxRating)
+	}
+
+	if comment, err = readLine("Enter comment: "); err != nil {
+		return 0, "", err
+	}
+
+	return rating, comment, nil
+}
@@ -0,0 +1,10 @@
+package models
+
+// MutationPreview - предпросмотр массового изменения до его выполнения:
+// ожидаемое количество затронутых строк и их часть
+type MutationPreview struct {
+	Operation    string
+	RowsAffected int64
+	Headers      []string
+	Sample       [][]any
+}
@@ -0,0 +1,11 @@
+package models
+
+// Ключи столбцов сортировки, допустимые в pager.Query.SortBy
+const (
+	SortByName        = "name"
+	SortByAlbum       = "album"
+	SortByArtist      = "artist"
+	SortByReleaseDate = "release_date"
+	SortByStreamCount = "stream_count"
+	SortByRank        = "rank"
+)
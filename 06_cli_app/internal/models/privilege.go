@@ -0,0 +1,11 @@
+package models
+
+// Privilege - право текущей роли на таблицу (проверяется через has_table_privilege)
+type Privilege struct {
+	Table     string
+	Privilege string
+}
+
+func TablePrivilege(table, privilege string) Privilege {
+	return Privilege{Table: table, Privilege: privilege}
+}
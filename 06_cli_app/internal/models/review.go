@@ -6,11 +6,44 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	MinRating = 1
+	MaxRating = 10
+)
+
+type ReviewTarget int
+
+const (
+	AlbumReview ReviewTarget = iota + 1
+	TrackReview
+)
+
+func (t ReviewTarget) String() string {
+	switch t {
+	case AlbumReview:
+		return "album"
+	case TrackReview:
+		return "track"
+	default:
+		return "unknown"
+	}
+}
+
 type Review struct {
 	ID         uuid.UUID
 	UserID     uuid.UUID
-	AlbumID    uuid.UUID
+	Target     ReviewTarget
+	TargetID   uuid.UUID // album_id или track_id в зависимости от Target
 	Rating     int
 	Comment    string
 	ReviewDate time.Time
 }
+
+// RatingSummary - средняя оценка и гистограмма оценок (индекс i - количество оценок i+1)
+type RatingSummary struct {
+	Target    ReviewTarget
+	TargetID  uuid.UUID
+	Count     int
+	Average   float64
+	Histogram [MaxRating]int
+}
@@ -0,0 +1,31 @@
+package models
+
+import "github.com/google/uuid"
+
+type SearchKind int
+
+const (
+	ArtistSearch SearchKind = iota + 1
+	AlbumSearch
+	TrackSearch
+)
+
+func (k SearchKind) String() string {
+	switch k {
+	case ArtistSearch:
+		return "artist"
+	case AlbumSearch:
+		return "album"
+	case TrackSearch:
+		return "track"
+	default:
+		return "unknown"
+	}
+}
+
+type SearchResult struct {
+	Kind    SearchKind
+	ID      uuid.UUID
+	Name    string
+	Details string
+}
@@ -1,8 +1,14 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type TracksAdditionalInfo struct {
+	TrackID     uuid.UUID
+	ArtistID    uuid.UUID
 	Name        string
 	Album       string
 	Artist      string
@@ -11,6 +17,7 @@ type TracksAdditionalInfo struct {
 }
 
 type RankedTrack struct {
+	ID          uuid.UUID
 	Name        string
 	StreamCount int
 	Rank        int
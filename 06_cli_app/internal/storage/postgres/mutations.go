@@ -0,0 +1,24 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hahaclassic/databases/06_cli_app/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// sampleSize - количество затронутых строк, показываемых в предпросмотре
+const sampleSize = 5
+
+// audit добавляет запись в audit_log в рамках транзакции изменения
+// (пользователь и роль берутся из session_user/current_user по умолчанию)
+func audit(ctx context.Context, tx pgx.Tx, operation, details string, rowsAffected int64) error {
+	_, err := tx.Exec(ctx, `INSERT INTO audit_log (operation, details, rows_affected)
+		VALUES ($1, $2, $3)`, operation, details, rowsAffected)
+	if err != nil {
+		return fmt.Errorf("%w: %w", storage.ErrAudit, err)
+	}
+
+	return nil
+}
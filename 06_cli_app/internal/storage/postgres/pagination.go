@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hahaclassic/databases/pkg/pager"
+)
+
+// keysetClauses возвращает условие на курсор (начиная с параметра $firstArg),
+// ORDER BY и LIMIT для страницы q.
+func keysetClauses[T any](k *pager.Keyset[T], q *pager.Query, firstArg int) (where, orderLimit string, args []any, err error) {
+	order, err := k.Order(q)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	where = "TRUE"
+	if order.Cursor != nil {
+		placeholders := make([]string, len(order.Cursor))
+		for i := range order.Cursor {
+			placeholders[i] = fmt.Sprintf("$%d", firstArg+i)
+		}
+		where = fmt.Sprintf("%s %s (%s)", order.Key(), order.Op(), strings.Join(placeholders, ", "))
+		args = order.Cursor
+	}
+
+	orderLimit = fmt.Sprintf("ORDER BY %s LIMIT %d", order.By(), order.Limit)
+
+	return where, orderLimit, args, nil
+}
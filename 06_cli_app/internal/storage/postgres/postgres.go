@@ -5,33 +5,70 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"sync/atomic"
 
 	"github.com/hahaclassic/databases/06_cli_app/config"
 	"github.com/hahaclassic/databases/06_cli_app/internal/storage"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type MusicServiceStorage struct {
 	db *pgxpool.Pool
+
+	// roles - роли из конфигурации, на которые разрешено переключение
+	roles []string
+	// role - текущая роль, устанавливаемая на каждом соединении пула
+	role atomic.Value
+
+	// trgm - установлено ли расширение pg_trgm (нечеткий поиск по названиям)
+	trgm bool
 }
 
 func New(ctx context.Context, config *config.PostgresConfig) (*MusicServiceStorage, error) {
 	dbURL := fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=%s",
 		config.User, config.Password, net.JoinHostPort(config.Host, config.Port), config.DB, config.SSLMode)
 
-	dbpool, err := pgxpool.New(ctx, dbURL)
+	poolConfig, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrStorageConnection, err)
+	}
+
+	s := &MusicServiceStorage{roles: config.Roles}
+	s.role.Store(config.Role)
+	poolConfig.AfterConnect = s.setConnRole
+
+	dbpool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", storage.ErrStorageConnection, err)
 	}
 
-	if dbpool.Ping(ctx) != nil {
+	if err = dbpool.Ping(ctx); err != nil {
 		return nil, fmt.Errorf("%w: %w", storage.ErrStorageConnection, err)
 	}
+	s.db = dbpool
 
-	return &MusicServiceStorage{dbpool}, nil
+	err = dbpool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm')").Scan(&s.trgm)
+	if err != nil {
+		slog.Warn("failed to check pg_trgm extension, fuzzy search disabled", "err", err)
+	}
+
+	return s, nil
 }
 
 func (s *MusicServiceStorage) Close() {
 	s.db.Close()
 	slog.Info("CONNECTION CLOSED")
 }
+
+// setConnRole устанавливает текущую роль на новом соединении пула
+func (s *MusicServiceStorage) setConnRole(ctx context.Context, conn *pgx.Conn) error {
+	role := s.role.Load().(string)
+	if role == "" {
+		return nil
+	}
+
+	_, err := conn.Exec(ctx, "SET ROLE "+pgx.Identifier{role}.Sanitize())
+
+	return err
+}
@@ -3,10 +3,12 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hahaclassic/databases/06_cli_app/internal/models"
 	"github.com/hahaclassic/databases/06_cli_app/internal/storage"
+	"github.com/hahaclassic/databases/pkg/pager"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
@@ -24,15 +26,23 @@ func (s *MusicServiceStorage) CountArtists(ctx context.Context) (int, error) {
 
 // 2. Запрос с несколькими соединениями (JOIN)
 // Получение треков с альбомами, артистами, датой релиза, количеством прослушиваний
-func (s *MusicServiceStorage) TracksAdditionalInfo(ctx context.Context, limit int) (tracks []*models.TracksAdditionalInfo, err error) {
+// (постранично, с фильтром по названию трека, альбома или имени артиста)
+func (s *MusicServiceStorage) TracksAdditionalInfo(ctx context.Context,
+	q *pager.Query) (page *pager.Page[*models.TracksAdditionalInfo], err error) {
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("%w: %w", storage.ErrTracksAdditionalInfo, err)
 		}
 	}()
 
+	where, orderLimit, cursorArgs, err := keysetClauses(tracksAdditionalInfoKeyset, q, 2)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-		SELECT t.name AS track_name,
+		SELECT t.id, a.id,
+			t.name AS track_name,
 			al.title AS album_title,
 			a.name AS artist_name,
 			al.release_date,
@@ -41,65 +51,105 @@ func (s *MusicServiceStorage) TracksAdditionalInfo(ctx context.Context, limit in
 		JOIN albums al ON t.album_id = al.id
 		JOIN albums_by_artists ab ON al.id = ab.album_id
 		JOIN artists a ON ab.artist_id = a.id
-		ORDER BY t.stream_count DESC LIMIT $1`
+		WHERE ($1 = '' OR t.name ILIKE '%' || $1 || '%'
+			OR al.title ILIKE '%' || $1 || '%' OR a.name ILIKE '%' || $1 || '%')
+			AND ` + where + `
+		` + orderLimit
 
-	rows, err := s.db.Query(ctx, query, limit)
+	rows, err := s.db.Query(ctx, query, append([]any{pager.EscapeLike(q.Filter)}, cursorArgs...)...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	var tracks []*models.TracksAdditionalInfo
 	for rows.Next() {
 		curr := &models.TracksAdditionalInfo{}
-		if err := rows.Scan(&curr.Name, &curr.Album, &curr.Artist,
+		if err := rows.Scan(&curr.TrackID, &curr.ArtistID, &curr.Name, &curr.Album, &curr.Artist,
 			&curr.ReleaseDate, &curr.StreamCount); err != nil {
 			return nil, err
 		}
 		tracks = append(tracks, curr)
 	}
 
-	if rows.Err() != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return tracks, nil
+	return tracksAdditionalInfoKeyset.Page(q, tracks)
+}
+
+var tracksAdditionalInfoKeyset = &pager.Keyset[*models.TracksAdditionalInfo]{
+	Columns: map[string]pager.Column[*models.TracksAdditionalInfo]{
+		models.SortByName:        {Expr: "t.name", Value: func(t *models.TracksAdditionalInfo) any { return t.Name }},
+		models.SortByAlbum:       {Expr: "al.title", Value: func(t *models.TracksAdditionalInfo) any { return t.Album }},
+		models.SortByArtist:      {Expr: "a.name", Value: func(t *models.TracksAdditionalInfo) any { return t.Artist }},
+		models.SortByReleaseDate: {Expr: "al.release_date", Value: func(t *models.TracksAdditionalInfo) any { return t.ReleaseDate }},
+		models.SortByStreamCount: {Expr: "t.stream_count", Value: func(t *models.TracksAdditionalInfo) any { return t.StreamCount }},
+	},
+	TieBreakers: []pager.Column[*models.TracksAdditionalInfo]{
+		{Expr: "t.id", Value: func(t *models.TracksAdditionalInfo) any { return t.TrackID }},
+		{Expr: "a.id", Value: func(t *models.TracksAdditionalInfo) any { return t.ArtistID }},
+	},
 }
 
 // 3. Запрос с ОТВ (CTE) и оконными функциями
-// Получение 5 самых популярных треков (название, кол-во прослушиваний, ранг)
-func (s *MusicServiceStorage) BestTracks(ctx context.Context, limit int) (tracks []*models.RankedTrack, err error) {
+// Получение limit самых популярных треков (название, кол-во прослушиваний, ранг)
+// (постранично, с фильтром по названию трека)
+func (s *MusicServiceStorage) BestTracks(ctx context.Context, limit int,
+	q *pager.Query) (page *pager.Page[*models.RankedTrack], err error) {
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("%w: %w", storage.ErrRankedTracks, err)
 		}
 	}()
 
+	where, orderLimit, cursorArgs, err := keysetClauses(rankedTracksKeyset, q, 3)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
         WITH RankedTracks AS (
-            SELECT name, stream_count, ROW_NUMBER() OVER (ORDER BY stream_count DESC) AS rank
+            SELECT id, name, stream_count, ROW_NUMBER() OVER (ORDER BY stream_count DESC) AS rank
             FROM tracks
         )
-        SELECT name, stream_count, rank FROM RankedTracks WHERE rank <= $1`
+        SELECT id, name, stream_count, rank FROM RankedTracks
+        WHERE rank <= $1 AND ($2 = '' OR name ILIKE '%' || $2 || '%')
+            AND ` + where + `
+        ` + orderLimit
 
-	rows, err := s.db.Query(ctx, query, limit)
+	rows, err := s.db.Query(ctx, query, append([]any{limit, pager.EscapeLike(q.Filter)}, cursorArgs...)...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	var tracks []*models.RankedTrack
 	for rows.Next() {
 		curr := &models.RankedTrack{}
-		if err := rows.Scan(&curr.Name, &curr.StreamCount, &curr.Rank); err != nil {
+		if err := rows.Scan(&curr.ID, &curr.Name, &curr.StreamCount, &curr.Rank); err != nil {
 			return nil, err
 		}
 		tracks = append(tracks, curr)
 	}
 
-	if rows.Err() != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return tracks, nil
+	return rankedTracksKeyset.Page(q, tracks)
+}
+
+var rankedTracksKeyset = &pager.Keyset[*models.RankedTrack]{
+	Columns: map[string]pager.Column[*models.RankedTrack]{
+		models.SortByRank:        {Expr: "rank", Value: func(t *models.RankedTrack) any { return t.Rank }},
+		models.SortByName:        {Expr: "name", Value: func(t *models.RankedTrack) any { return t.Name }},
+		models.SortByStreamCount: {Expr: "stream_count", Value: func(t *models.RankedTrack) any { return t.StreamCount }},
+	},
+	TieBreakers: []pager.Column[*models.RankedTrack]{
+		{Expr: "id", Value: func(t *models.RankedTrack) any { return t.ID }},
+	},
 }
 
 // 4. Запрос к метаданным
@@ -177,15 +227,75 @@ func (s *MusicServiceStorage) ArtistAlbums(ctx context.Context, artistID uuid.UU
 	return albums, nil
 }
 
-// 7. Вызов хранимой процедуры без параметров
+// Предпросмотр выдачи премиума: сколько пользователей затронет процедура и часть из них.
+// Кандидаты выбираются той же функцией pensioners_without_premium, что и в процедуре.
+func (s *MusicServiceStorage) PensionersPreview(ctx context.Context) (preview *models.MutationPreview, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%w: %w", storage.ErrFreePremiumForPensioners, err)
+		}
+	}()
+
+	preview = &models.MutationPreview{
+		Operation: "FreePremiumForPensioners",
+		Headers:   []string{"User ID", "Name", "Birth Date"},
+	}
+
+	rows, err := s.db.Query(ctx, `SELECT id, name, birth_date, COUNT(*) OVER ()
+		FROM pensioners_without_premium() ORDER BY birth_date LIMIT $1`, sampleSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id        uuid.UUID
+			name      string
+			birthDate time.Time
+		)
+		if err = rows.Scan(&id, &name, &birthDate, &preview.RowsAffected); err != nil {
+			return nil, err
+		}
+		preview.Sample = append(preview.Sample, []any{id, name, birthDate.Format("2006-01-02")})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+// 7. Вызов хранимой процедуры (с выходным параметром INOUT affected)
 // Процедура для выдачи премиума всем пользователям от 65 лет.
-func (s *MusicServiceStorage) FreePremiumForPensioners(ctx context.Context) error {
-	_, err := s.db.Exec(ctx, "call free_premium_to_pensioners()")
+// Возвращает количество измененных строк, которое сохраняется в audit_log в той же транзакции.
+func (s *MusicServiceStorage) FreePremiumForPensioners(ctx context.Context) (affected int64, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%w: %w", storage.ErrFreePremiumForPensioners, err)
+		}
+	}()
+
+	tx, err := s.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("%w: %w", storage.ErrFreePremiumForPensioners, err)
+		return 0, err
 	}
+	defer func() { _ = tx.Rollback(ctx) }()
 
-	return nil
+	if err = tx.QueryRow(ctx, "CALL free_premium_to_pensioners()").Scan(&affected); err != nil {
+		return 0, err
+	}
+
+	if err = audit(ctx, tx, "FreePremiumForPensioners", "premium for 1 year", affected); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return affected, nil
 }
 
 // 8. Вызов системную функцию или процедуру
@@ -200,39 +310,23 @@ func (s *MusicServiceStorage) CurrentUser(ctx context.Context) (string, error) {
 	return currentUser, nil
 }
 
-// 9. Создание таблицы в базе данных
-// Создание таблицы ревью на альбомы
-func (s *MusicServiceStorage) CreateReviewsTable(ctx context.Context) error {
-	query := `CREATE TABLE reviews (
-		id UUID PRIMARY KEY,                  
-		user_id UUID,                         
-		album_id UUID,                         
-		rating INT CHECK (rating >= 1 AND rating <= 10),
-		comment TEXT,                          
-		review_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE, 
-		FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE)`
-
-	_, err := s.db.Exec(ctx, query)
-	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "42P07" {
-			return fmt.Errorf("%w: %w", storage.ErrTableAlreadyExists, err)
-		}
-		return fmt.Errorf("%w: %w", storage.ErrCreateTable, err)
-	}
-
-	return nil
-}
-
 // 10. Вставка данных в созданную таблицу с помощью INSERT
 // Добавление ревью
 func (s *MusicServiceStorage) AddReview(ctx context.Context, review *models.Review) error {
-	query := `INSERT INTO reviews (id, user_id, album_id, rating, comment) VALUES 
-		($1, $2, $3, $4, $5)`
+	column, err := reviewTargetColumn(review.Target)
+	if err != nil {
+		return fmt.Errorf("%w: %w", storage.ErrAddReview, err)
+	}
+
+	query := fmt.Sprintf(`INSERT INTO reviews (id, user_id, %s, rating, comment) VALUES 
+		($1, $2, $3, $4, $5)`, column)
 
-	_, err := s.db.Exec(ctx, query, review.ID, review.UserID, review.AlbumID,
+	_, err = s.db.Exec(ctx, query, review.ID, review.UserID, review.TargetID,
 		review.Rating, review.Comment)
 	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			return fmt.Errorf("%w: %w", storage.ErrAlreadyReviewed, err)
+		}
 		return fmt.Errorf("%w: %w", storage.ErrAddReview, err)
 	}
 
@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/06_cli_app/internal/models"
+	"github.com/hahaclassic/databases/06_cli_app/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+var errUnknownReviewTarget = errors.New("unknown review target")
+
+const reviewColumns = `id, user_id, album_id, track_id, rating, COALESCE(comment, ''), review_date`
+
+func reviewTargetColumn(target models.ReviewTarget) (string, error) {
+	switch target {
+	case models.AlbumReview:
+		return "album_id", nil
+	case models.TrackReview:
+		return "track_id", nil
+	default:
+		return "", fmt.Errorf("%w: %d", errUnknownReviewTarget, target)
+	}
+}
+
+func scanReviews(rows pgx.Rows) (reviews []*models.Review, err error) {
+	defer rows.Close()
+
+	for rows.Next() {
+		var albumID, trackID uuid.NullUUID
+		curr := &models.Review{}
+		if err := rows.Scan(&curr.ID, &curr.UserID, &albumID, &trackID,
+			&curr.Rating, &curr.Comment, &curr.ReviewDate); err != nil {
+			return nil, err
+		}
+
+		if albumID.Valid {
+			curr.Target, curr.TargetID = models.AlbumReview, albumID.UUID
+		} else {
+			curr.Target, curr.TargetID = models.TrackReview, trackID.UUID
+		}
+		reviews = append(reviews, curr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+// Получение всех ревью на альбом или трек
+func (s *MusicServiceStorage) Reviews(ctx context.Context, target models.ReviewTarget,
+	targetID uuid.UUID) (reviews []*models.Review, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%w: %w", storage.ErrReviews, err)
+		}
+	}()
+
+	column, err := reviewTargetColumn(target)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, fmt.Sprintf(`SELECT %s FROM reviews
+		WHERE %s = $1 ORDER BY review_date DESC`, reviewColumns, column), targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanReviews(rows)
+}
+
+// Получение всех ревью пользователя
+func (s *MusicServiceStorage) UserReviews(ctx context.Context, userID uuid.UUID) (reviews []*models.Review, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%w: %w", storage.ErrReviews, err)
+		}
+	}()
+
+	rows, err := s.db.Query(ctx, fmt.Sprintf(`SELECT %s FROM reviews
+		WHERE user_id = $1 ORDER BY review_date DESC`, reviewColumns), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanReviews(rows)
+}
+
+// Изменение оценки и комментария ревью
+func (s *MusicServiceStorage) UpdateReview(ctx context.Context, review *models.Review) error {
+	query := `UPDATE reviews SET rating = $2, comment = $3, review_date = CURRENT_TIMESTAMP
+		WHERE id = $1`
+
+	tag, err := s.db.Exec(ctx, query, review.ID, review.Rating, review.Comment)
+	if err != nil {
+		return fmt.Errorf("%w: %w", storage.ErrUpdateReview, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %w", storage.ErrUpdateReview, storage.ErrNotFound)
+	}
+
+	return nil
+}
+
+// Удаление ревью
+func (s *MusicServiceStorage) DeleteReview(ctx context.Context, reviewID uuid.UUID) error {
+	tag, err := s.db.Exec(ctx, "DELETE FROM reviews WHERE id = $1", reviewID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", storage.ErrDeleteReview, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %w", storage.ErrDeleteReview, storage.ErrNotFound)
+	}
+
+	return nil
+}
+
+// Средняя оценка и гистограмма оценок альбома или трека
+func (s *MusicServiceStorage) RatingSummary(ctx context.Context, target models.ReviewTarget,
+	targetID uuid.UUID) (summary *models.RatingSummary, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%w: %w", storage.ErrRatingSummary, err)
+		}
+	}()
+
+	column, err := reviewTargetColumn(target)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`SELECT rating, COUNT(*) FROM reviews
+		WHERE %s = $1 GROUP BY rating ORDER BY rating`, column)
+
+	rows, err := s.db.Query(ctx, query, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary = &models.RatingSummary{Target: target, TargetID: targetID}
+	total := 0
+	for rows.Next() {
+		var rating, count int
+		if err := rows.Scan(&rating, &count); err != nil {
+			return nil, err
+		}
+		if rating < models.MinRating || rating > models.MaxRating {
+			continue
+		}
+
+		summary.Histogram[rating-models.MinRating] = count
+		summary.Count += count
+		total += rating * count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if summary.Count > 0 {
+		summary.Average = float64(total) / float64(summary.Count)
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/hahaclassic/databases/06_cli_app/internal/models"
+	"github.com/hahaclassic/databases/06_cli_app/internal/storage"
+)
+
+// Получение ролей из конфигурации, членом которых является пользователь подключения
+func (s *MusicServiceStorage) Roles(ctx context.Context) (roles []string, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%w: %w", storage.ErrRoles, err)
+		}
+	}()
+
+	query := `
+		SELECT r FROM unnest($1::text[]) AS r
+		WHERE CASE WHEN EXISTS (SELECT 1 FROM pg_roles WHERE rolname = r)
+			THEN pg_has_role(session_user, r, 'MEMBER') ELSE false END`
+
+	rows, err := s.db.Query(ctx, query, s.roles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// Переключение роли (SET ROLE) для всех соединений. Пустая строка
+// возвращает роль пользователя подключения.
+func (s *MusicServiceStorage) SetRole(ctx context.Context, role string) error {
+	if role != "" {
+		roles, err := s.Roles(ctx)
+		if err != nil {
+			return fmt.Errorf("%w: %w", storage.ErrSetRole, err)
+		}
+
+		if !slices.Contains(roles, role) {
+			return fmt.Errorf("%w: %w: %s", storage.ErrSetRole, storage.ErrRoleNotAllowed, role)
+		}
+	}
+
+	// соединения пула пересоздаются и получают новую роль в AfterConnect
+	prev := s.role.Swap(role)
+	s.db.Reset()
+
+	if err := s.db.Ping(ctx); err != nil {
+		s.role.Store(prev)
+		s.db.Reset()
+		return fmt.Errorf("%w: %w", storage.ErrSetRole, err)
+	}
+
+	return nil
+}
+
+// Проверка прав текущей роли. Права на несуществующие таблицы считаются отсутствующими.
+func (s *MusicServiceStorage) CheckPrivileges(ctx context.Context, privileges []models.Privilege) (granted []bool, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%w: %w", storage.ErrCheckPrivileges, err)
+		}
+	}()
+
+	tables := make([]string, len(privileges))
+	types := make([]string, len(privileges))
+	for i, p := range privileges {
+		tables[i], types[i] = p.Table, p.Privilege
+	}
+
+	query := `
+		SELECT CASE WHEN to_regclass(name) IS NULL THEN false ELSE has_table_privilege(name, priv) END
+		FROM unnest($1::text[], $2::text[]) WITH ORDINALITY AS p(name, priv, n)
+		ORDER BY n`
+
+	rows, err := s.db.Query(ctx, query, tables, types)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ok bool
+		if err := rows.Scan(&ok); err != nil {
+			return nil, err
+		}
+		granted = append(granted, ok)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return granted, nil
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hahaclassic/databases/06_cli_app/internal/models"
+	"github.com/hahaclassic/databases/06_cli_app/internal/storage"
+	"github.com/hahaclassic/databases/pkg/pager"
+)
+
+var errUnknownSearchKind = errors.New("unknown search kind")
+
+// searchSource - подзапрос с колонками id, name, details для каждого типа поиска
+func searchSource(kind models.SearchKind) (string, error) {
+	switch kind {
+	case models.ArtistSearch:
+		return `SELECT id, name, concat_ws(', ', genre, country) AS details FROM artists`, nil
+	case models.AlbumSearch:
+		return `SELECT id, title AS name,
+			concat_ws(', ', genre, EXTRACT(YEAR FROM release_date)::TEXT) AS details FROM albums`, nil
+	case models.TrackSearch:
+		return `SELECT t.id, t.name, COALESCE(al.title, '') AS details
+			FROM tracks t JOIN albums al ON al.id = t.album_id`, nil
+	default:
+		return "", fmt.Errorf("%w: %d", errUnknownSearchKind, kind)
+	}
+}
+
+// Поиск исполнителей, альбомов или треков по части названия.
+// При наличии pg_trgm результаты ранжируются по триграммному сходству
+// (допускаются опечатки), иначе используется ILIKE.
+func (s *MusicServiceStorage) Search(ctx context.Context, kind models.SearchKind,
+	pattern string, limit int) (results []*models.SearchResult, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%w: %w", storage.ErrSearch, err)
+		}
+	}()
+
+	source, err := searchSource(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		query string
+		args  = []any{pager.EscapeLike(pattern)}
+	)
+	if s.trgm {
+		query = fmt.Sprintf(`SELECT id, name, details FROM (%s) src
+			WHERE name ILIKE '%%' || $1 || '%%' OR $2 <%% name
+			ORDER BY word_similarity($2, name) DESC, name
+			LIMIT $3`, source)
+		args = append(args, pattern, limit)
+	} else {
+		query = fmt.Sprintf(`SELECT id, name, details FROM (%s) src
+			WHERE name ILIKE '%%' || $1 || '%%'
+			ORDER BY name ILIKE $1 || '%%' DESC, length(name), name
+			LIMIT $2`, source)
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		curr := &models.SearchResult{Kind: kind}
+		if err := rows.Scan(&curr.ID, &curr.Name, &curr.Details); err != nil {
+			return nil, err
+		}
+		results = append(results, curr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
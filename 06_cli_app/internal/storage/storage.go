@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/hahaclassic/databases/06_cli_app/internal/models"
+	"github.com/hahaclassic/databases/pkg/pager"
 )
 
 var (
@@ -17,26 +18,46 @@ var (
 	ErrAlbumsInfo               = errors.New("failed to get artist albums")
 	ErrFreePremiumForPensioners = errors.New("failed to set premium")
 	ErrCurrentUser              = errors.New("failed to get current user")
-	ErrCreateTable              = errors.New("failed to create table")
 	ErrAddReview                = errors.New("failed to add review")
+	ErrReviews                  = errors.New("failed to get reviews")
+	ErrUpdateReview             = errors.New("failed to update review")
+	ErrDeleteReview             = errors.New("failed to delete review")
+	ErrRatingSummary            = errors.New("failed to get rating summary")
+	ErrSearch                   = errors.New("failed to search")
+	ErrRoles                    = errors.New("failed to get roles")
+	ErrSetRole                  = errors.New("failed to set role")
+	ErrCheckPrivileges          = errors.New("failed to check privileges")
+	ErrAudit                    = errors.New("failed to write audit entry")
 
-	ErrTableAlreadyExists = errors.New("table already exists")
-	ErrStorageConnection  = errors.New("storage: can't connect to the database")
-	ErrNoRowsAffected     = errors.New("no rows affected")
-	ErrNotFound           = errors.New("not found")
+	ErrStorageConnection = errors.New("storage: can't connect to the database")
+	ErrNoRowsAffected    = errors.New("no rows affected")
+	ErrNotFound          = errors.New("not found")
+	ErrAlreadyReviewed   = errors.New("user has already reviewed this item")
+	ErrRoleNotAllowed    = errors.New("role is not allowed")
 )
 
 type MusicServiceStorage interface {
 	CountArtists(ctx context.Context) (int, error)
-	TracksAdditionalInfo(ctx context.Context, limit int) (tracks []*models.TracksAdditionalInfo, err error)
-	BestTracks(ctx context.Context, limit int) (tracks []*models.RankedTrack, err error)
+	TracksAdditionalInfo(ctx context.Context, q *pager.Query) (*pager.Page[*models.TracksAdditionalInfo], error)
+	BestTracks(ctx context.Context, limit int, q *pager.Query) (*pager.Page[*models.RankedTrack], error)
 	TablesNames(ctx context.Context) (names []string, err error)
 	CountArtistTracks(ctx context.Context, artistID uuid.UUID) (int, error)
 	ArtistAlbums(ctx context.Context, artistID uuid.UUID) (tracks []*models.Album, err error)
-	FreePremiumForPensioners(ctx context.Context) error
+	PensionersPreview(ctx context.Context) (*models.MutationPreview, error)
+	FreePremiumForPensioners(ctx context.Context) (int64, error)
 	CurrentUser(ctx context.Context) (string, error)
-	CreateReviewsTable(ctx context.Context) error
 	AddReview(ctx context.Context, review *models.Review) error
+	Reviews(ctx context.Context, target models.ReviewTarget, targetID uuid.UUID) ([]*models.Review, error)
+	UserReviews(ctx context.Context, userID uuid.UUID) ([]*models.Review, error)
+	UpdateReview(ctx context.Context, review *models.Review) error
+	DeleteReview(ctx context.Context, reviewID uuid.UUID) error
+	RatingSummary(ctx context.Context, target models.ReviewTarget, targetID uuid.UUID) (*models.RatingSummary, error)
+
+	Search(ctx context.Context, kind models.SearchKind, pattern string, limit int) ([]*models.SearchResult, error)
+
+	Roles(ctx context.Context) ([]string, error)
+	SetRole(ctx context.Context, role string) error
+	CheckPrivileges(ctx context.Context, privileges []models.Privilege) ([]bool, error)
 
 	Close()
 }
@@ -0,0 +1,14 @@
+-- Миграция таблицы reviews, созданной старыми версиями приложения (только ревью на альбомы).
+-- Новые базы получают таблицу из 01_init/sql/create_tables.sql; скрипт можно выполнять повторно.
+ALTER TABLE reviews
+ALTER COLUMN user_id SET NOT NULL,
+ALTER COLUMN album_id DROP NOT NULL,
+ADD COLUMN IF NOT EXISTS track_id UUID REFERENCES tracks(id) ON DELETE CASCADE;
+
+ALTER TABLE reviews
+DROP CONSTRAINT IF EXISTS check_review_target,
+DROP CONSTRAINT IF EXISTS unique_user_album_review,
+DROP CONSTRAINT IF EXISTS unique_user_track_review,
+ADD CONSTRAINT check_review_target CHECK (num_nonnulls(album_id, track_id) = 1),
+ADD CONSTRAINT unique_user_album_review UNIQUE (user_id, album_id),
+ADD CONSTRAINT unique_user_track_review UNIQUE (user_id, track_id);
@@ -1,2 +1,50 @@
+-- Средняя оценка и гистограмма оценок альбомов
+SELECT album_id, AVG(rating), COUNT(*) FROM reviews WHERE album_id IS NOT NULL GROUP BY album_id;
+SELECT rating, COUNT(*) FROM reviews WHERE album_id = :'album_id' GROUP BY rating ORDER BY rating;
 
-SELECT * from reviews;
\ No newline at end of file
+-- Нечеткий поиск по названиям (используется, если установлено расширение pg_trgm)
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_artists_name_trgm ON artists USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_albums_title_trgm ON albums USING gin (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_tracks_name_trgm ON tracks USING gin (name gin_trgm_ops);
+
+SELECT name, word_similarity('beatls', name) FROM artists WHERE 'beatls' <% name ORDER BY 2 DESC LIMIT 10;
+
+-- Журнал массовых изменений (FreePremiumForPensioners), запись добавляется
+-- в той же транзакции, что и изменение
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    db_user VARCHAR(100) NOT NULL DEFAULT session_user,
+    db_role VARCHAR(100) NOT NULL DEFAULT current_user,
+    performed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT clock_timestamp(),
+    operation VARCHAR(100) NOT NULL,
+    details TEXT,
+    rows_affected BIGINT NOT NULL
+);
+
+-- Пенсионеры без премиума (удаленные пользователи не учитываются): общее условие
+-- для предпросмотра в приложении и процедуры
+CREATE OR REPLACE FUNCTION pensioners_without_premium()
+RETURNS SETOF users
+LANGUAGE sql STABLE
+AS $$
+    SELECT * FROM users
+    WHERE premium = false AND EXTRACT(YEAR FROM AGE(birth_date)) >= 65
+      AND deleted_at IS NULL;
+$$;
+
+-- Процедура выдачи премиума возвращает количество измененных строк
+-- (сигнатура изменилась, поэтому версия без параметров удаляется)
+DROP PROCEDURE IF EXISTS free_premium_to_pensioners();
+CREATE OR REPLACE PROCEDURE free_premium_to_pensioners(INOUT affected BIGINT DEFAULT NULL)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    UPDATE users
+    SET premium = true,
+        premium_expiration = CURRENT_DATE + INTERVAL '1 year'
+    WHERE id IN (SELECT id FROM pensioners_without_premium());
+
+    GET DIAGNOSTICS affected = ROW_COUNT;
+END;
+$$;
@@ -0,0 +1,31 @@
+-- Роли приложения (POSTGRES_ROLES), на которые пользователь POSTGRES_USER
+-- переключается через SET ROLE
+
+-- Аналитик: только чтение
+CREATE ROLE music_analyst NOLOGIN;
+GRANT USAGE ON SCHEMA public TO music_analyst;
+GRANT SELECT ON ALL TABLES IN SCHEMA public TO music_analyst;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT ON TABLES TO music_analyst;
+
+-- Редактор: чтение и изменение данных
+CREATE ROLE music_editor NOLOGIN;
+GRANT music_analyst TO music_editor;
+GRANT INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO music_editor;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT INSERT, UPDATE, DELETE ON TABLES TO music_editor;
+-- записи в audit_log используют последовательность id
+GRANT USAGE ON ALL SEQUENCES IN SCHEMA public TO music_editor;
+
+-- Администратор: изменение данных и схемы
+CREATE ROLE music_admin NOLOGIN;
+GRANT music_editor TO music_admin;
+GRANT CREATE ON SCHEMA public TO music_admin;
+-- таблицы, созданные под ролью администратора (например, reviews), доступны остальным ролям
+ALTER DEFAULT PRIVILEGES FOR ROLE music_admin IN SCHEMA public GRANT SELECT ON TABLES TO music_analyst;
+ALTER DEFAULT PRIVILEGES FOR ROLE music_admin IN SCHEMA public GRANT INSERT, UPDATE, DELETE ON TABLES TO music_editor;
+
+GRANT music_analyst, music_editor, music_admin TO admin;
+
+-- Проверка прав текущей роли
+SET ROLE music_analyst;
+SELECT current_user, has_table_privilege('users', 'UPDATE'), has_schema_privilege('public', 'CREATE');
+RESET ROLE;
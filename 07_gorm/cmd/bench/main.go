@@ -0,0 +1,73 @@
+// bench сравнивает одинаковые операции через pgx и GORM
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/hahaclassic/databases/07_gorm/config"
+	"github.com/hahaclassic/databases/07_gorm/internal/bench"
+)
+
+func main() {
+	cfg := &bench.Config{}
+	flag.IntVar(&cfg.Iterations, "iterations", 1000, "runs of each operation")
+	flag.IntVar(&cfg.Concurrency, "concurrency", 4, "parallel workers (and pool connections)")
+	flag.IntVar(&cfg.TopN, "top", 10, "limit for top tracks")
+	flag.IntVar(&cfg.BatchSize, "batch", 100, "users per bulk insert")
+	ops := flag.String("ops", "", "comma-separated operations (default all: "+strings.Join(bench.Operations(), ",")+")")
+	format := flag.String("format", "table", "report format: table or csv")
+	out := flag.String("out", "", "csv output file (default stdout)")
+	skipTx := flag.Bool("gorm-skip-tx", false, "disable GORM default transaction for writes")
+	flag.Parse()
+
+	if cfg.Iterations < 1 || cfg.Concurrency < 1 || cfg.TopN < 1 || cfg.BatchSize < 1 {
+		log.Fatal("iterations, concurrency, top and batch must be positive")
+	}
+	if *format != "table" && *format != "csv" {
+		log.Fatalf("unknown format %q", *format)
+	}
+	if *ops != "" {
+		cfg.Operations = strings.Split(*ops, ",")
+	}
+
+	pgCfg := &config.MustLoad().Postres
+	ctx := context.Background()
+
+	pgxDriver, err := bench.NewPgxDriver(ctx, pgCfg, cfg.Concurrency)
+	if err != nil {
+		log.Fatalf("pgx: %v", err)
+	}
+	defer pgxDriver.Close()
+
+	gormDriver, err := bench.NewGormDriver(pgCfg, cfg.Concurrency, *skipTx)
+	if err != nil {
+		log.Fatalf("gorm: %v", err)
+	}
+	defer gormDriver.Close()
+
+	results, err := bench.Run(ctx, cfg, []bench.Driver{pgxDriver, gormDriver})
+	if err != nil {
+		log.Printf("Benchmark stopped: %v", err)
+	}
+
+	if *format == "table" {
+		bench.PrintTable(results)
+		return
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		if w, err = os.Create(*out); err != nil {
+			log.Fatalf("Error creating %s: %v", *out, err)
+		}
+		defer w.Close()
+	}
+
+	if err := bench.WriteCSV(w, results); err != nil {
+		log.Printf("Error writing CSV: %v", err)
+	}
+}
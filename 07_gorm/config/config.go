@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"net"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -12,6 +15,7 @@ const (
 
 type Config struct {
 	Postres PostgresConfig
+	Purge   PurgeConfig
 }
 
 type PostgresConfig struct {
@@ -21,6 +25,35 @@ type PostgresConfig struct {
 	Host     string `env:"POSTGRES_HOST"`
 	Port     string `env:"POSTGRES_PORT"`
 	SSLMode  string `env:"POSTGRES_SSL_MODE"`
+
+	Log SQLLogConfig
+}
+
+// URL - строка подключения к Postgres
+func (c *PostgresConfig) URL() string {
+	return fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=%s",
+		c.User, c.Password, net.JoinHostPort(c.Host, c.Port), c.DB, c.SSLMode)
+}
+
+// SQLLogConfig - журнал SQL-запросов GORM
+type SQLLogConfig struct {
+	// Level - silent, error, warn (только медленные запросы и ошибки) или info (все запросы)
+	Level string `env:"SQL_LOG_LEVEL" env-default:"warn"`
+	// File - файл журнала (пусто - stderr)
+	File string `env:"SQL_LOG_FILE"`
+	// SlowThreshold - запросы дольше порога помечаются как медленные
+	SlowThreshold time.Duration `env:"SQL_SLOW_THRESHOLD" env-default:"200ms"`
+	// ExplainFile - файл для планов EXPLAIN (ANALYZE, BUFFERS) медленных
+	// SELECT-запросов (пусто - планы не собираются)
+	ExplainFile string `env:"SQL_EXPLAIN_FILE"`
+}
+
+// PurgeConfig - фоновое окончательное удаление мягко удаленных записей
+type PurgeConfig struct {
+	// Retention - сколько хранить мягко удаленные записи
+	Retention time.Duration `env:"PURGE_RETENTION" env-default:"720h"`
+	// Interval - период запуска (0 - фоновая очистка отключена)
+	Interval time.Duration `env:"PURGE_INTERVAL" env-default:"1h"`
 }
 
 func MustLoad() *Config {
@@ -10,7 +10,8 @@ import (
 )
 
 func Start(cfg *config.Config) {
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	db, err := postgres.New(ctx, &cfg.Postres)
 	if err != nil {
@@ -18,5 +19,12 @@ func Start(cfg *config.Config) {
 		return
 	}
 
+	go runPurge(ctx, db, &cfg.Purge)
+
 	controller.NewController(db).Start(ctx)
+
+	cancel()
+	if err := db.Close(); err != nil {
+		slog.Error("POSTGRES", "err", err)
+	}
 }
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/hahaclassic/databases/07_gorm/config"
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+)
+
+// runPurge периодически окончательно удаляет записи, мягко удаленные
+// раньше, чем cfg.Retention назад
+func runPurge(ctx context.Context, db storage.Storage, cfg *config.PurgeConfig) {
+	if cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		purge(ctx, db, cfg.Retention)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func purge(ctx context.Context, db storage.Storage, retention time.Duration) {
+	// очистка выполняется без подтверждения, если есть что удалять
+	affected, err := db.PurgeDeleted(ctx, time.Now().Add(-retention), func(preview *models.MutationPreview) bool {
+		return preview.RowsAffected > 0
+	})
+	if errors.Is(err, storage.ErrNotConfirmed) {
+		return
+	} else if err != nil {
+		slog.Error("PURGE", "err", err)
+		return
+	}
+	slog.Info("PURGE", "rows", affected)
+}
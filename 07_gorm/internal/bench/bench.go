@@ -0,0 +1,282 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/pkg/report"
+)
+
+// namePrefix - префикс имен пользователей, создаваемых этим запуском бенчмарка:
+// идентификатор запуска в нем позволяет Cleanup удалять только свои строки
+var namePrefix = fmt.Sprintf("bench %s ", uuid.NewString()[:8])
+
+var ErrUnknownOperation = errors.New("unknown benchmark operation")
+
+type Config struct {
+	// Iterations - число выполнений каждой операции
+	Iterations int
+	// Concurrency - число параллельных воркеров (и соединений в пуле)
+	Concurrency int
+	// TopN - limit для top-N треков
+	TopN int
+	// BatchSize - пользователей в одной пакетной вставке
+	BatchSize int
+	// Operations - какие операции запускать (пусто - все)
+	Operations []string
+}
+
+// run - одно выполнение операции; i - номер итерации
+type run func(ctx context.Context, i int) error
+
+// operation подготавливает данные для iterations запусков (вне замера) и
+// возвращает замеряемую функцию
+type operation struct {
+	name    string
+	prepare func(ctx context.Context, d Driver, cfg *Config) (run, error)
+}
+
+var operations = []operation{
+	{"top_tracks", prepareTopTracks},
+	{"genre_counts", prepareGenreCounts},
+	{"insert_user", prepareInsertUser},
+	{"update_user", prepareUpdateUser},
+	{"delete_user", prepareDeleteUser},
+	{"bulk_insert", prepareBulkInsert},
+}
+
+// Operations - названия всех операций в порядке запуска
+func Operations() []string {
+	names := make([]string, 0, len(operations))
+	for _, op := range operations {
+		names = append(names, op.name)
+	}
+
+	return names
+}
+
+// Result - результат одной операции на одном драйвере
+type Result struct {
+	Driver      string
+	Operation   string
+	Iterations  int
+	Concurrency int
+	Errors      int
+	Elapsed     time.Duration
+	Mean        time.Duration
+	P50         time.Duration
+	P90         time.Duration
+	P99         time.Duration
+	Max         time.Duration
+	// AllocsPerOp, BytesPerOp - выделения памяти процессом за замер, деленные на число итераций
+	AllocsPerOp uint64
+	BytesPerOp  uint64
+}
+
+// OpsPerSec - пропускная способность
+func (r *Result) OpsPerSec() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+
+	return float64(r.Iterations) / r.Elapsed.Seconds()
+}
+
+// Run выполняет выбранные операции на каждом драйвере по очереди
+func Run(ctx context.Context, cfg *Config, drivers []Driver) ([]*Result, error) {
+	ops, err := selectOperations(cfg.Operations)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Result, 0, len(ops)*len(drivers))
+	for _, op := range ops {
+		for _, d := range drivers {
+			r, err := runOperation(ctx, cfg, d, op)
+			// созданных пользователей удаляем и после неудачной подготовки
+			if _, cleanupErr := d.Cleanup(ctx); cleanupErr != nil {
+				err = errors.Join(err, cleanupErr)
+			}
+			if err != nil {
+				return results, fmt.Errorf("%s/%s: %w", d.Name(), op.name, err)
+			}
+			results = append(results, r)
+		}
+	}
+
+	return results, nil
+}
+
+func selectOperations(names []string) ([]operation, error) {
+	if len(names) == 0 {
+		return operations, nil
+	}
+
+	ops := make([]operation, 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(operations, func(op operation) bool { return op.name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownOperation, name)
+		}
+		ops = append(ops, operations[i])
+	}
+
+	return ops, nil
+}
+
+func runOperation(ctx context.Context, cfg *Config, d Driver, op operation) (*Result, error) {
+	fn, err := op.prepare(ctx, d, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	latencies := make([]time.Duration, cfg.Iterations)
+	var (
+		next   atomic.Int64
+		errCnt atomic.Int64
+		wg     sync.WaitGroup
+	)
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	for range cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= cfg.Iterations {
+					return
+				}
+
+				begin := time.Now()
+				if err := fn(ctx, i); err != nil {
+					errCnt.Add(1)
+				}
+				latencies[i] = time.Since(begin)
+			}
+		}()
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	n := uint64(cfg.Iterations)
+	r := &Result{
+		Driver:      d.Name(),
+		Operation:   op.name,
+		Iterations:  cfg.Iterations,
+		Concurrency: cfg.Concurrency,
+		Errors:      int(errCnt.Load()),
+		Elapsed:     elapsed,
+		AllocsPerOp: (after.Mallocs - before.Mallocs) / n,
+		BytesPerOp:  (after.TotalAlloc - before.TotalAlloc) / n,
+	}
+	fillLatencies(r, latencies)
+
+	return r, nil
+}
+
+// fillLatencies считает среднее и процентили (nearest-rank)
+func fillLatencies(r *Result, latencies []time.Duration) {
+	l := report.Summarize(latencies)
+	r.Mean, r.P50, r.P90, r.P99, r.Max = l.Mean, l.P50, l.P90, l.P99, l.Max
+}
+
+func prepareTopTracks(_ context.Context, d Driver, cfg *Config) (run, error) {
+	return func(ctx context.Context, _ int) error {
+		_, err := d.TopTracks(ctx, cfg.TopN)
+		return err
+	}, nil
+}
+
+func prepareGenreCounts(_ context.Context, d Driver, _ *Config) (run, error) {
+	return func(ctx context.Context, _ int) error {
+		_, err := d.GenreCounts(ctx)
+		return err
+	}, nil
+}
+
+func prepareInsertUser(_ context.Context, d Driver, cfg *Config) (run, error) {
+	users := newUsers(cfg.Iterations)
+
+	return func(ctx context.Context, i int) error {
+		return d.InsertUser(ctx, users[i])
+	}, nil
+}
+
+func prepareUpdateUser(ctx context.Context, d Driver, cfg *Config) (run, error) {
+	users, err := seedUsers(ctx, d, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, i int) error {
+		return d.UpdateUserName(ctx, users[i].ID, users[i].Name+" (updated)")
+	}, nil
+}
+
+func prepareDeleteUser(ctx context.Context, d Driver, cfg *Config) (run, error) {
+	users, err := seedUsers(ctx, d, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, i int) error {
+		return d.DeleteUser(ctx, users[i].ID)
+	}, nil
+}
+
+func prepareBulkInsert(_ context.Context, d Driver, cfg *Config) (run, error) {
+	batches := make([][]*models.User, cfg.Iterations)
+	for i := range batches {
+		batches[i] = newUsers(cfg.BatchSize)
+	}
+
+	return func(ctx context.Context, i int) error {
+		return d.InsertUsers(ctx, batches[i])
+	}, nil
+}
+
+// seedUsers вставляет по пользователю на итерацию, чтобы итерации не конкурировали за строки
+func seedUsers(ctx context.Context, d Driver, cfg *Config) ([]*models.User, error) {
+	users := newUsers(cfg.Iterations)
+	for batch := range slices.Chunk(users, max(cfg.BatchSize, 1)) {
+		if err := d.InsertUsers(ctx, batch); err != nil {
+			return nil, err
+		}
+	}
+
+	return users, nil
+}
+
+func newUsers(n int) []*models.User {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	birthDate := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	users := make([]*models.User, n)
+	for i := range users {
+		id := uuid.New()
+		users[i] = &models.User{
+			ID:                id,
+			Name:              namePrefix + id.String()[:8],
+			RegistrationDate:  now,
+			BirthDate:         models.JsonDate(birthDate),
+			PremiumExpiration: now,
+		}
+	}
+
+	return users
+}
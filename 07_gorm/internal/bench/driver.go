@@ -0,0 +1,26 @@
+package bench
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+)
+
+// Driver - одни и те же операции, выполняемые через разные стеки доступа к данным
+type Driver interface {
+	Name() string
+	// TopTracks - limit самых прослушиваемых треков
+	TopTracks(ctx context.Context, limit int) ([]*models.Track, error)
+	// GenreCounts - количество треков по жанрам
+	GenreCounts(ctx context.Context) ([]*models.GenreCount, error)
+	InsertUser(ctx context.Context, user *models.User) error
+	UpdateUserName(ctx context.Context, id uuid.UUID, name string) error
+	// DeleteUser удаляет пользователя окончательно (без мягкого удаления)
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	// InsertUsers вставляет пользователей одним запросом
+	InsertUsers(ctx context.Context, users []*models.User) error
+	// Cleanup удаляет пользователей, созданных этим запуском бенчмарка
+	Cleanup(ctx context.Context) (int64, error)
+	Close()
+}
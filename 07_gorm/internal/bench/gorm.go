@@ -0,0 +1,87 @@
+package bench
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/07_gorm/config"
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// GormDriver - те же операции через GORM (как в storage/postgres)
+type GormDriver struct {
+	db *gorm.DB
+}
+
+// NewGormDriver открывает GORM без логирования запросов. skipTx отключает
+// транзакцию, в которую GORM по умолчанию оборачивает каждую запись.
+func NewGormDriver(cfg *config.PostgresConfig, conns int, skipTx bool) (*GormDriver, error) {
+	db, err := gorm.Open(postgres.Open(cfg.URL()), &gorm.Config{
+		Logger:                 logger.Default.LogMode(logger.Silent),
+		SkipDefaultTransaction: skipTx,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(conns)
+	sqlDB.SetMaxIdleConns(conns)
+
+	return &GormDriver{db: db}, nil
+}
+
+func (d *GormDriver) Name() string {
+	return "gorm"
+}
+
+func (d *GormDriver) TopTracks(ctx context.Context, limit int) ([]*models.Track, error) {
+	tracks := []*models.Track{}
+	err := d.db.WithContext(ctx).Order("stream_count DESC").Limit(limit).Find(&tracks).Error
+
+	return tracks, err
+}
+
+func (d *GormDriver) GenreCounts(ctx context.Context) ([]*models.GenreCount, error) {
+	genres := []*models.GenreCount{}
+	err := d.db.WithContext(ctx).Table("tracks").
+		Select("genre, count(*) as count").Group("genre").Order("count DESC").
+		Find(&genres).Error
+
+	return genres, err
+}
+
+func (d *GormDriver) InsertUser(ctx context.Context, user *models.User) error {
+	return d.db.WithContext(ctx).Omit("version", "deleted_at").Create(user).Error
+}
+
+func (d *GormDriver) UpdateUserName(ctx context.Context, id uuid.UUID, name string) error {
+	return d.db.WithContext(ctx).Model(&models.User{ID: id}).Update("name", name).Error
+}
+
+func (d *GormDriver) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return d.db.WithContext(ctx).Unscoped().Delete(&models.User{ID: id}).Error
+}
+
+// InsertUsers вставляет пользователей одним многострочным INSERT
+func (d *GormDriver) InsertUsers(ctx context.Context, users []*models.User) error {
+	return d.db.WithContext(ctx).Omit("version", "deleted_at").Create(users).Error
+}
+
+func (d *GormDriver) Cleanup(ctx context.Context) (int64, error) {
+	res := d.db.WithContext(ctx).Unscoped().Where("name LIKE ?", namePrefix+"%").Delete(&models.User{})
+
+	return res.RowsAffected, res.Error
+}
+
+func (d *GormDriver) Close() {
+	if sqlDB, err := d.db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
@@ -0,0 +1,134 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/07_gorm/config"
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// userColumns - порядок колонок при вставке пользователей через pgx
+var userColumns = []string{"id", "name", "registration_date", "birth_date", "premium", "premium_expiration"}
+
+// PgxDriver - операции на чистом SQL через pgxpool (как в 06_cli_app и 09_redis)
+type PgxDriver struct {
+	db *pgxpool.Pool
+}
+
+func NewPgxDriver(ctx context.Context, cfg *config.PostgresConfig, conns int) (*PgxDriver, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.URL())
+	if err != nil {
+		return nil, err
+	}
+	poolConfig.MaxConns = int32(conns)
+
+	db, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &PgxDriver{db: db}, nil
+}
+
+func (d *PgxDriver) Name() string {
+	return "pgx"
+}
+
+func (d *PgxDriver) TopTracks(ctx context.Context, limit int) ([]*models.Track, error) {
+	rows, err := d.db.Query(ctx, `SELECT id, name, order_in_album, album_id, explicit, duration, genre,
+		stream_count, metadata FROM tracks ORDER BY stream_count DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.Track, error) {
+		t := &models.Track{}
+		err := row.Scan(&t.ID, &t.Name, &t.OrderInAlbum, &t.AlbumID, &t.Explicit, &t.Duration,
+			&t.Genre, &t.StreamCount, &t.Metadata)
+
+		return t, err
+	})
+}
+
+func (d *PgxDriver) GenreCounts(ctx context.Context) ([]*models.GenreCount, error) {
+	rows, err := d.db.Query(ctx, `SELECT genre, count(*) AS count FROM tracks
+		GROUP BY genre ORDER BY count DESC`)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.GenreCount, error) {
+		g := &models.GenreCount{}
+		err := row.Scan(&g.Genre, &g.Count)
+
+		return g, err
+	})
+}
+
+func (d *PgxDriver) InsertUser(ctx context.Context, u *models.User) error {
+	_, err := d.db.Exec(ctx, `INSERT INTO users (id, name, registration_date, birth_date, premium, premium_expiration)
+		VALUES ($1, $2, $3, $4, $5, $6)`, userValues(u)...)
+
+	return err
+}
+
+func (d *PgxDriver) UpdateUserName(ctx context.Context, id uuid.UUID, name string) error {
+	_, err := d.db.Exec(ctx, "UPDATE users SET name = $1 WHERE id = $2 AND deleted_at IS NULL", name, id)
+
+	return err
+}
+
+func (d *PgxDriver) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := d.db.Exec(ctx, "DELETE FROM users WHERE id = $1", id)
+
+	return err
+}
+
+// InsertUsers вставляет пользователей одним многострочным INSERT (как GORM)
+func (d *PgxDriver) InsertUsers(ctx context.Context, users []*models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	rows := make([]string, 0, len(users))
+	args := make([]any, 0, len(users)*len(userColumns))
+	for _, u := range users {
+		placeholders := make([]string, len(userColumns))
+		for i := range placeholders {
+			placeholders[i] = fmt.Sprintf("$%d", len(args)+i+1)
+		}
+		rows = append(rows, "("+strings.Join(placeholders, ", ")+")")
+		args = append(args, userValues(u)...)
+	}
+
+	_, err := d.db.Exec(ctx, "INSERT INTO users ("+strings.Join(userColumns, ", ")+") VALUES "+
+		strings.Join(rows, ", "), args...)
+
+	return err
+}
+
+func (d *PgxDriver) Cleanup(ctx context.Context) (int64, error) {
+	tag, err := d.db.Exec(ctx, "DELETE FROM users WHERE name LIKE $1", namePrefix+"%")
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (d *PgxDriver) Close() {
+	d.db.Close()
+}
+
+func userValues(u *models.User) []any {
+	return []any{u.ID, u.Name, u.RegistrationDate, u.BirthDate, u.Premium, u.PremiumExpiration}
+}
@@ -0,0 +1,39 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	tableoutput "github.com/hahaclassic/databases/07_gorm/pkg/table"
+	"github.com/hahaclassic/databases/pkg/report"
+	"github.com/jedib0t/go-pretty/table"
+)
+
+var reportHeaders = []string{"Operation", "Driver", "Iterations", "Concurrency", "Errors",
+	"Ops/s", "Mean", "P50", "P90", "P99", "Max", "Allocs/op", "Bytes/op"}
+
+// PrintTable выводит результаты таблицей
+func PrintTable(results []*Result) {
+	rows := make([][]interface{}, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, []interface{}{r.Operation, r.Driver, r.Iterations, r.Concurrency, r.Errors,
+			fmt.Sprintf("%.1f", r.OpsPerSec()), report.Round(r.Mean), report.Round(r.P50), report.Round(r.P90),
+			report.Round(r.P99), report.Round(r.Max), r.AllocsPerOp, r.BytesPerOp})
+	}
+
+	tableoutput.PrintTable(table.StyleColoredDark, reportHeaders, rows)
+}
+
+// WriteCSV пишет результаты в CSV; длительности - в микросекундах
+func WriteCSV(w io.Writer, results []*Result) error {
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, []string{r.Operation, r.Driver, strconv.Itoa(r.Iterations),
+			strconv.Itoa(r.Concurrency), strconv.Itoa(r.Errors), strconv.FormatFloat(r.OpsPerSec(), 'f', 1, 64),
+			report.Micros(r.Mean), report.Micros(r.P50), report.Micros(r.P90), report.Micros(r.P99),
+			report.Micros(r.Max), strconv.FormatUint(r.AllocsPerOp, 10), strconv.FormatUint(r.BytesPerOp, 10)})
+	}
+
+	return report.WriteCSV(w, reportHeaders, rows)
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	"github.com/jedib0t/go-pretty/list"
+)
+
+// Дискография исполнителя: исполнитель -> альбомы -> треки
+func (c *Controller) ArtistDiscography(ctx context.Context) {
+	artistID, err := readUUID("Enter artist ID: ")
+	if err != nil {
+		slog.Error("Invalid UUID format", "err", err)
+		return
+	}
+
+	artist, err := c.storage.ArtistDiscography(ctx, artistID)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("Artist not found.")
+		return
+	} else if err != nil {
+		log.Printf("Error getting artist discography: %v", err)
+		return
+	}
+
+	l := newTree()
+	l.AppendItem(fmt.Sprintf("%s (%s, %s, since %d)", artist.Name, artist.Genre, artist.Country, artist.DebutYear))
+	l.Indent()
+	for _, album := range artist.Albums {
+		l.AppendItem(fmt.Sprintf("%s [%s, %s]", album.Title, album.ReleaseDate.Format("2006-01-02"), album.Label))
+		l.Indent()
+		for _, track := range album.Tracks {
+			l.AppendItem(fmt.Sprintf("%d. %s %s%s", track.OrderInAlbum, track.Name,
+				formatDuration(track.Duration), featuring(artist, track.Artists)))
+		}
+		l.UnIndent()
+	}
+	l.Render()
+}
+
+// Библиотека пользователя: пользователь -> плейлисты -> треки
+func (c *Controller) UserLibrary(ctx context.Context) {
+	userID, err := readUUID("Enter user ID: ")
+	if err != nil {
+		slog.Error("Invalid UUID format", "err", err)
+		return
+	}
+
+	user, err := c.storage.UserLibrary(ctx, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("User not found.")
+		return
+	} else if err != nil {
+		log.Printf("Error getting user library: %v", err)
+		return
+	}
+
+	l := newTree()
+	l.AppendItem(fmt.Sprintf("%s (premium: %t)", user.Name, user.Premium))
+	l.Indent()
+	for _, up := range user.Playlists {
+		if up.Playlist == nil {
+			continue
+		}
+
+		mark := ""
+		if up.IsFavorite {
+			mark = " *"
+		}
+		l.AppendItem(fmt.Sprintf("%s%s (%d tracks)", up.Playlist.Title, mark, len(up.Playlist.Tracks)))
+		l.Indent()
+		for _, pt := range up.Playlist.Tracks {
+			if pt.Track == nil {
+				continue
+			}
+
+			album := ""
+			if pt.Track.Album != nil {
+				album = " - " + pt.Track.Album.Title
+			}
+			l.AppendItem(fmt.Sprintf("%d. %s%s%s", pt.TrackOrder, pt.Track.Name,
+				featuring(nil, pt.Track.Artists), album))
+		}
+		l.UnIndent()
+	}
+	l.Render()
+}
+
+func newTree() list.Writer {
+	l := list.NewWriter()
+	l.SetOutputMirror(os.Stdout)
+	l.SetStyle(list.StyleConnectedRounded)
+
+	return l
+}
+
+// featuring перечисляет исполнителей трека, кроме main
+func featuring(main *models.Artist, artists []*models.Artist) string {
+	names := make([]string, 0, len(artists))
+	for _, a := range artists {
+		if main == nil || a.ID != main.ID {
+			names = append(names, a.Name)
+		}
+	}
+
+	if len(names) == 0 {
+		return ""
+	}
+	if main == nil {
+		return " by " + strings.Join(names, ", ")
+	}
+
+	return " feat. " + strings.Join(names, ", ")
+}
+
+func formatDuration(seconds int) string {
+	return fmt.Sprintf("(%d:%02d)", seconds/60, seconds%60)
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -13,6 +14,7 @@ import (
 	"github.com/hahaclassic/databases/07_gorm/internal/models"
 	"github.com/hahaclassic/databases/07_gorm/internal/storage"
 	tableoutput "github.com/hahaclassic/databases/07_gorm/pkg/table"
+	"github.com/hahaclassic/databases/pkg/pager"
 	"github.com/jedib0t/go-pretty/table"
 )
 
@@ -40,13 +42,31 @@ func (c *Controller) Start(ctx context.Context) {
 		AlbumsByArtist:               c.AlbumsByArtist,
 		ExportUsersToJSON:            c.ExportUsersToJSON,
 		ImportUsersFromJSON:          c.ImportUsersFromJSON,
+		ArtistDiscography:            c.ArtistDiscography,
+		UserLibrary:                  c.UserLibrary,
+		ExportEntity:                 c.ExportEntity,
+		ImportEntity:                 c.ImportEntity,
+		ExportSubgraph:               c.ExportSubgraph,
+		ImportSubgraph:               c.ImportSubgraph,
+		ExportDiscographies:          c.ExportDiscographies,
+		ImportDiscographies:          c.ImportDiscographies,
+		FindByMetadata:               c.FindByMetadata,
+		UpdateMetadata:               c.UpdateMetadata,
+		DeletePlaylist:               c.DeletePlaylist,
+		RestoreUser:                  c.RestoreUser,
+		RestorePlaylist:              c.RestorePlaylist,
+		Trash:                        c.Trash,
+		PurgeDeleted:                 c.PurgeDeleted,
+		UpdatePlaylist:               c.UpdatePlaylist,
+		EditHistory:                  c.EditHistory,
+		SearchTracks:                 c.SearchTracks,
 	}
 
 	for {
 		menu()
 		fmt.Print("Enter operation number (or 0 for exit): ")
 		var input string
-		_, err := fmt.Scanln(&input)
+		_, err := fmt.Fscanln(stdin, &input)
 		if err != nil {
 			fmt.Println("Invalid operation number. Try again.")
 		}
@@ -68,7 +88,7 @@ func (c *Controller) Start(ctx context.Context) {
 func (c *Controller) BestExplicitTracks(ctx context.Context) {
 	var limit int
 	fmt.Print("Enter limit for best tracks: ")
-	_, err := fmt.Scan(&limit)
+	_, err := fmt.Fscan(stdin, &limit)
 	if err != nil {
 		slog.Error("[ERR]", "err", err)
 		return
@@ -117,7 +137,7 @@ func (c *Controller) CountTracksByGenre(ctx context.Context) {
 func (c *Controller) AlbumsWithMaxTracks(ctx context.Context) {
 	var minNumOfTracks int
 	fmt.Print("Enter max number for tracks: ")
-	_, err := fmt.Scan(&minNumOfTracks)
+	_, err := fmt.Fscan(stdin, &minNumOfTracks)
 	if err != nil {
 		slog.Error("[ERR]", "err", err)
 		return
@@ -146,7 +166,7 @@ func (c *Controller) AlbumsWithMaxTracks(ctx context.Context) {
 func (c *Controller) ArtistsWithReleasedAlbumYear(ctx context.Context) {
 	var year int
 	fmt.Print("Enter the release year: ")
-	_, err := fmt.Scan(&year)
+	_, err := fmt.Fscan(stdin, &year)
 	if err != nil {
 		slog.Error("[ERR]", "err", err)
 		return
@@ -175,68 +195,56 @@ func (c *Controller) ArtistsWithReleasedAlbumYear(ctx context.Context) {
 func (c *Controller) UsersOlderThan(ctx context.Context) {
 	var age int
 	fmt.Print("Enter the minimum age: ")
-	_, err := fmt.Scan(&age)
+	_, err := fmt.Fscanln(stdin, &age)
 	if err != nil {
 		slog.Error("[ERR]", "err", err)
 		return
 	}
 
-	users, err := c.storage.UsersOlderThan(ctx, age)
-	if err != nil {
-		log.Printf("Error getting users older than %d: %v", age, err)
-		return
-	}
-
-	if len(users) == 0 {
-		fmt.Println("No users found.")
-		return
+	p := &pager.Pager[*models.User]{
+		Headers: []string{"ID", "Name", "Registration", "Birth Date", "Premium", "Prem. Exp"},
+		SortColumns: []string{models.SortByName, models.SortByBirthDate,
+			models.SortByRegistrationDate, models.SortByPremiumExpiration},
+		Fetch: func(ctx context.Context, q *pager.Query) (*pager.Page[*models.User], error) {
+			return c.storage.UsersOlderThan(ctx, age, q)
+		},
+		Row: func(user *models.User) []interface{} {
+			return []interface{}{user.ID, user.Name, user.RegistrationDate,
+				user.BirthDate, user.Premium, user.PremiumExpiration}
+		},
 	}
 
-	headers := []string{"ID", "Name", "Registration", "Birth Date", "Premium", "Prem. Exp"}
-	rows := [][]interface{}{}
-	for _, user := range users {
-		rows = append(rows, []interface{}{user.ID, user.Name, user.RegistrationDate,
-			user.BirthDate, user.Premium, user.PremiumExpiration})
-	}
-
-	tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+	runPager(ctx, p)
 }
 
 // Получение всех треков одного жанра
 func (c *Controller) TracksByGenre(ctx context.Context) {
-	var genre string
-	fmt.Print("Enter genre: ")
-	_, err := fmt.Scan(&genre)
+	genre, err := readLine("Enter genre: ")
 	if err != nil {
 		slog.Error("[ERR]", "err", err)
 		return
 	}
 
-	tracks, err := c.storage.TracksByGenre(ctx, genre)
-	if err != nil {
-		log.Printf("Error getting tracks by genre: %v", err)
-		return
-	}
-
-	if len(tracks) == 0 {
-		fmt.Println("No tracks found.")
-		return
-	}
-
-	headers := []string{"ID", "Name", "...", "Genre", "Stream Count"}
-	rows := [][]interface{}{}
-	for _, track := range tracks {
-		rows = append(rows, []interface{}{track.ID, track.Name, "...", track.Genre, track.StreamCount})
+	p := &pager.Pager[*models.Track]{
+		Headers:     []string{"ID", "Name", "Duration", "Genre", "Stream Count"},
+		SortColumns: []string{models.SortByStreamCount, models.SortByName, models.SortByDuration},
+		Desc:        true,
+		Fetch: func(ctx context.Context, q *pager.Query) (*pager.Page[*models.Track], error) {
+			return c.storage.TracksByGenre(ctx, genre, q)
+		},
+		Row: func(track *models.Track) []interface{} {
+			return []interface{}{track.ID, track.Name, track.Duration, track.Genre, track.StreamCount}
+		},
 	}
 
-	tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+	runPager(ctx, p)
 }
 
 // Многотабличный запрос
 func (c *Controller) AlbumsWithTrackCounts(ctx context.Context) {
 	var genre string
 	fmt.Print("Enter genre: ")
-	_, err := fmt.Scan(&genre)
+	_, err := fmt.Fscan(stdin, &genre)
 	if err != nil {
 		slog.Error("[ERR]", "err", err)
 		return
@@ -267,7 +275,7 @@ func (c *Controller) AddUser(ctx context.Context) {
 	user := models.User{}
 
 	fmt.Print("Enter user name: ")
-	_, err := fmt.Scan(&user.Name)
+	_, err := fmt.Fscan(stdin, &user.Name)
 	if err != nil {
 		slog.Error("[ERR]", "err", err)
 		return
@@ -302,7 +310,7 @@ func (Controller) setPremium() (bool, time.Time, error) {
 		premiumExp time.Time
 	)
 	fmt.Print("Is the user premium? (true/false): ")
-	_, err := fmt.Scan(&premium)
+	_, err := fmt.Fscan(stdin, &premium)
 	if err != nil {
 		slog.Error("[ERR]", "err", err)
 		return false, time.Time{}, err
@@ -311,7 +319,7 @@ func (Controller) setPremium() (bool, time.Time, error) {
 	if premium {
 		fmt.Print("Enter premium expiration date (YYYY-MM-DD): ")
 		var premiumExpiration string
-		_, err = fmt.Scan(&premiumExpiration)
+		_, err = fmt.Fscan(stdin, &premiumExpiration)
 		if err != nil {
 			slog.Error("[ERR]", "err", err)
 			return false, time.Time{}, err
@@ -328,49 +336,55 @@ func (Controller) setPremium() (bool, time.Time, error) {
 	return premium, premiumExp, nil
 }
 
-func (Controller) userBirthDay() (models.JsonBirthDate, error) {
+func (Controller) userBirthDay() (models.JsonDate, error) {
 	fmt.Print("Enter birth date (YYYY-MM-DD): ")
 	var birthDateStr string
-	_, err := fmt.Scan(&birthDateStr)
+	_, err := fmt.Fscan(stdin, &birthDateStr)
 	if err != nil {
-		return models.JsonBirthDate(time.Now()), err
+		return models.JsonDate(time.Now()), err
 	}
 
 	birthDate, err := time.Parse("2006-01-02", birthDateStr)
 	if err != nil {
-		return models.JsonBirthDate(time.Now()), err
+		return models.JsonDate(time.Now()), err
 	}
 
-	return models.JsonBirthDate(birthDate), nil
+	return models.JsonDate(birthDate), nil
 }
 
 // Обновление имени пользователя
 func (c *Controller) UpdateUserName(ctx context.Context) {
-	var userIDStr string
-	var newName string
-
-	fmt.Print("Enter user ID: ")
-	_, err := fmt.Scan(&userIDStr)
+	userID, err := readUUID("Enter user ID: ")
 	if err != nil {
-		slog.Error("Failed to read user ID", "err", err)
+		slog.Error("Invalid UUID format", "err", err)
+		fmt.Println("Please enter a valid UUID.")
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		slog.Error("Invalid UUID format", "err", err)
-		fmt.Println("Please enter a valid UUID.")
+	user, err := c.storage.User(ctx, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("User not found.")
+		return
+	} else if err != nil {
+		log.Printf("Error getting user: %v", err)
 		return
 	}
+	fmt.Printf("Current name: %s (version %d)\n", user.Name, user.Version)
 
-	fmt.Print("Enter new user name: ")
-	_, err = fmt.Scan(&newName)
-	if err != nil {
+	newName, err := readLine("Enter new user name: ")
+	if err != nil || newName == "" {
 		slog.Error("Failed to read new name", "err", err)
 		return
 	}
 
-	if err := c.storage.UpdateUserName(ctx, userID, newName); err != nil {
+	err = c.storage.UpdateUserName(ctx, userID, user.Version, newName)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("User not found.")
+		return
+	} else if errors.Is(err, storage.ErrVersionConflict) {
+		fmt.Println("User was modified by someone else. Reload and try again.")
+		return
+	} else if err != nil {
 		log.Printf("Error updating user name: %v", err)
 		return
 	}
@@ -383,7 +397,7 @@ func (c *Controller) DeleteUser(ctx context.Context) {
 	var userIDStr string
 
 	fmt.Print("Enter user ID to delete: ")
-	_, err := fmt.Scan(&userIDStr)
+	_, err := fmt.Fscanln(stdin, &userIDStr)
 	if err != nil {
 		slog.Error("Failed to read user ID", "err", err)
 		return
@@ -396,12 +410,18 @@ func (c *Controller) DeleteUser(ctx context.Context) {
 		return
 	}
 
-	if err := c.storage.DeleteUser(ctx, userID); err != nil {
+	_, err = c.storage.DeleteUser(ctx, userID, confirmMutation)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("User not found.")
+		return
+	} else if errors.Is(err, storage.ErrNotConfirmed) {
+		return
+	} else if err != nil {
 		log.Printf("Error deleting user: %v", err)
 		return
 	}
 
-	fmt.Println("User deleted successfully.")
+	fmt.Println("User deleted (it can be restored until purged).")
 }
 
 // Получение альбомов исполнителя
@@ -409,7 +429,7 @@ func (c *Controller) AlbumsByArtist(ctx context.Context) {
 	var artistIDStr string
 
 	fmt.Print("Enter artist ID: ")
-	_, err := fmt.Scan(&artistIDStr)
+	_, err := fmt.Fscan(stdin, &artistIDStr)
 	if err != nil {
 		slog.Error("Failed to read artist ID", "err", err)
 		return
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+)
+
+// Выгрузка документов дискографии: NDJSON-файлом или файлом на исполнителя
+func (c *Controller) ExportDiscographies(ctx context.Context) {
+	input, err := readLine("Enter artist ID (empty for all artists): ")
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	artistID := uuid.Nil
+	if input != "" {
+		if artistID, err = uuid.Parse(input); err != nil {
+			slog.Error("Invalid UUID format", "err", err)
+			return
+		}
+	}
+
+	format, err := readFormat()
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	var count int
+	var target string
+	if format == models.FormatNDJSON {
+		target = readFileName("export", "./data/discographies.ndjson")
+		count, err = exportToFile(target, func(f *os.File) (int, error) {
+			enc := json.NewEncoder(f)
+			return c.storage.Discographies(ctx, artistID, func(doc *models.DiscographyDocument) error {
+				return enc.Encode(doc)
+			})
+		})
+	} else {
+		target = readFileName("export (directory)", "./data/discographies")
+		if err = os.MkdirAll(target, 0o755); err != nil {
+			slog.Error("Error creating directory", "err", err)
+			return
+		}
+
+		count, err = c.storage.Discographies(ctx, artistID, func(doc *models.DiscographyDocument) error {
+			_, err := exportToFile(filepath.Join(target, doc.ID.String()+".json"), func(f *os.File) (int, error) {
+				enc := json.NewEncoder(f)
+				enc.SetIndent("", "  ")
+				return 1, enc.Encode(doc)
+			})
+			return err
+		})
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("Artist not found.")
+		return
+	} else if err != nil {
+		slog.Error("Error exporting discographies", "err", err)
+		return
+	}
+
+	fmt.Printf("%d discographies successfully exported to '%s'\n", count, target)
+}
+
+// Загрузка документов дискографии из NDJSON/JSON-файла или каталога с файлами документов
+func (c *Controller) ImportDiscographies(ctx context.Context) {
+	source := readFileName("import (file or directory)", "./data/discographies.ndjson")
+
+	documents, err := readDiscographies(source)
+	if err != nil {
+		slog.Error("Error reading discographies", "err", err)
+		return
+	}
+
+	report, err := c.storage.ImportDiscographies(ctx, documents, confirmMutation)
+	if errors.Is(err, storage.ErrNotConfirmed) {
+		return
+	} else if err != nil {
+		slog.Error("Error importing discographies", "err", err)
+		return
+	}
+
+	printImportReport(report)
+}
+
+func readDiscographies(source string) ([]*models.DiscographyDocument, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return decodeDiscographies(source)
+	}
+
+	files, err := filepath.Glob(filepath.Join(source, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []*models.DiscographyDocument
+	for _, file := range files {
+		docs, err := decodeDiscographies(file)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, docs...)
+	}
+
+	return documents, nil
+}
+
+// decodeDiscographies читает документы файла: по одному на строку,
+// одиночный документ или JSON-массив документов
+func decodeDiscographies(fileName string) ([]*models.DiscographyDocument, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var documents []*models.DiscographyDocument
+	dec := json.NewDecoder(f)
+	for {
+		var value json.RawMessage
+		if err := dec.Decode(&value); err == io.EOF {
+			return documents, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %w", fileName, err)
+		}
+
+		if value[0] == '[' {
+			var docs []*models.DiscographyDocument
+			if err := json.Unmarshal(value, &docs); err != nil {
+				return nil, fmt.Errorf("%s: %w", fileName, err)
+			}
+			documents = append(documents, docs...)
+			continue
+		}
+
+		var doc models.DiscographyDocument
+		if err := json.Unmarshal(value, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", fileName, err)
+		}
+		documents = append(documents, &doc)
+	}
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	tableoutput "github.com/hahaclassic/databases/07_gorm/pkg/table"
+	"github.com/jedib0t/go-pretty/table"
+)
+
+const historyLimit = 50
+
+// Изменение плейлиста (пустой ввод оставляет поле без изменений)
+func (c *Controller) UpdatePlaylist(ctx context.Context) {
+	playlistID, err := readUUID("Enter playlist ID: ")
+	if err != nil {
+		slog.Error("Invalid UUID format", "err", err)
+		return
+	}
+
+	playlist, err := c.storage.Playlist(ctx, playlistID)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("Playlist not found.")
+		return
+	} else if err != nil {
+		log.Printf("Error getting playlist: %v", err)
+		return
+	}
+	fmt.Printf("Current: %q, private: %t (version %d)\n", playlist.Title, playlist.Private, playlist.Version)
+
+	update := &models.PlaylistUpdate{}
+	if update.Title, err = readOptional("New title: "); err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+	if update.Description, err = readOptional("New description: "); err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	private, err := readOptional("Private (true/false): ")
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+	if private != nil {
+		value, err := strconv.ParseBool(*private)
+		if err != nil {
+			slog.Error("Invalid boolean", "value", *private)
+			return
+		}
+		update.Private = &value
+	}
+
+	err = c.storage.UpdatePlaylist(ctx, playlistID, playlist.Version, update)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("Playlist not found.")
+		return
+	} else if errors.Is(err, storage.ErrVersionConflict) {
+		fmt.Println("Playlist was modified by someone else. Reload and try again.")
+		return
+	} else if err != nil {
+		log.Printf("Error updating playlist: %v", err)
+		return
+	}
+
+	fmt.Println("Playlist updated successfully.")
+}
+
+// История изменений пользователей или плейлистов
+func (c *Controller) EditHistory(ctx context.Context) {
+	entity, err := readEntity([]models.Entity{models.Users, models.Playlists})
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	input, err := readLine("Enter record ID (empty for all records): ")
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	recordID := uuid.Nil
+	if input != "" {
+		if recordID, err = uuid.Parse(input); err != nil {
+			slog.Error("Invalid UUID format", "err", err)
+			return
+		}
+	}
+
+	edits, err := c.storage.EditHistory(ctx, entity, recordID, historyLimit)
+	if err != nil {
+		log.Printf("Error getting edit history: %v", err)
+		return
+	}
+
+	if len(edits) == 0 {
+		fmt.Println("No edits found.")
+		return
+	}
+
+	headers := []string{"Edited At", "Record ID", "Field", "Old Value", "New Value", "Version", "DB User"}
+	rows := [][]any{}
+	for _, e := range edits {
+		rows = append(rows, []any{e.EditedAt.Format(time.DateTime), e.RecordID, e.Field,
+			e.OldValue, e.NewValue, e.Version, e.DBUser})
+	}
+
+	tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+}
+
+// readOptional возвращает nil для пустого ввода
+func readOptional(prompt string) (*string, error) {
+	input, err := readLine(prompt)
+	if err != nil || input == "" {
+		return nil, err
+	}
+
+	return &input, nil
+}
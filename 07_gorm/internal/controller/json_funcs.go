@@ -3,42 +3,64 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 
 	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	tableoutput "github.com/hahaclassic/databases/07_gorm/pkg/table"
+	"github.com/jedib0t/go-pretty/table"
 )
 
 func (c *Controller) ExportUsersToJSON(ctx context.Context) {
 	var fileName string
 
 	fmt.Print("Enter the file name for export (default: ./data/users.json): ")
-	_, err := fmt.Scanln(&fileName)
+	_, err := fmt.Fscanln(stdin, &fileName)
 	if err != nil || fileName == "" {
 		fileName = "./data/users.json"
 	}
 
-	jsonData, err := c.storage.ExportUsersToJSON(ctx)
+	count, err := exportToFile(fileName, func(f *os.File) (int, error) {
+		return c.storage.ExportUsersToJSON(ctx, f)
+	})
 	if err != nil {
 		slog.Error("Error exporting users to JSON", "err", err)
 		return
 	}
 
-	err = os.WriteFile(fileName, jsonData, 0644)
+	fmt.Printf("%d users successfully exported to '%s'\n", count, fileName)
+}
+
+// exportToFile пишет выгрузку во временный файл и переименовывает его после
+// успешного завершения, чтобы не оставлять частично записанный файл
+func exportToFile(fileName string, export func(f *os.File) (int, error)) (int, error) {
+	tmpName := fileName + ".tmp"
+
+	f, err := os.Create(tmpName)
 	if err != nil {
-		slog.Error("Error writing JSON to file", "err", err)
-		return
+		return 0, err
 	}
 
-	fmt.Printf("Users successfully exported to '%s'\n", fileName)
+	count, err := export(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpName)
+		return 0, err
+	}
+
+	return count, os.Rename(tmpName, fileName)
 }
 
 func (c *Controller) ImportUsersFromJSON(ctx context.Context) {
 	var fileName string
 
 	fmt.Print("Enter the file name for import (default: ./data/users.json): ")
-	_, err := fmt.Scanln(&fileName)
+	_, err := fmt.Fscanln(stdin, &fileName)
 	if err != nil || fileName == "" {
 		fileName = "./data/users.json"
 	}
@@ -55,11 +77,28 @@ func (c *Controller) ImportUsersFromJSON(ctx context.Context) {
 		return
 	}
 
-	err = c.storage.ImportUsers(ctx, users)
-	if err != nil {
+	report, err := c.storage.ImportUsers(ctx, users, confirmMutation)
+	if errors.Is(err, storage.ErrNotConfirmed) {
+		return
+	} else if err != nil {
 		slog.Error("Error importing users from JSON", "err", err)
 		return
 	}
 
-	fmt.Printf("Successfully imported.\n")
+	printImportReport(report)
+}
+
+func printImportReport(report *models.ImportReport) {
+	fmt.Printf("Records: %d, imported: %d, rejected: %d\n", report.Total, report.Imported, len(report.Errors))
+	if len(report.Errors) == 0 {
+		return
+	}
+
+	headers := []string{"Entity", "#", "Key", "Error"}
+	rows := [][]interface{}{}
+	for _, e := range report.Errors {
+		rows = append(rows, []interface{}{e.Entity, e.Index, e.Key, e.Err})
+	}
+
+	tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
 }
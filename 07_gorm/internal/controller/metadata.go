@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	tableoutput "github.com/hahaclassic/databases/07_gorm/pkg/table"
+	"github.com/jedib0t/go-pretty/table"
+)
+
+const defaultMetadataLimit = 20
+
+var metadataEntities = []models.Entity{models.Artists, models.Albums, models.Tracks}
+
+// Поиск исполнителей, альбомов или треков по metadata
+func (c *Controller) FindByMetadata(ctx context.Context) {
+	entity, err := readEntity(metadataEntities)
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	filter := &models.MetadataFilter{Entity: entity, Limit: defaultMetadataLimit}
+	fields := []struct {
+		prompt string
+		value  *string
+	}{
+		{`Contains (JSON, e.g. {"mood_tags": ["calm"]}, empty to skip): `, &filter.Contains},
+		{"Predicate (jsonpath, e.g. $.bpm > 120, empty to skip): ", &filter.Predicate},
+		{"Select (jsonpath, e.g. $.credits[*].name, empty to skip): ", &filter.Select},
+	}
+	for _, field := range fields {
+		if *field.value, err = readLine(field.prompt); err != nil {
+			slog.Error("[ERR]", "err", err)
+			return
+		}
+	}
+
+	input, err := readLine(fmt.Sprintf("Limit (default: %d): ", defaultMetadataLimit))
+	if err == nil && input != "" {
+		if filter.Limit, err = strconv.Atoi(input); err != nil || filter.Limit <= 0 {
+			slog.Error("Invalid limit", "limit", input)
+			return
+		}
+	}
+
+	matches, err := c.storage.FindByMetadata(ctx, filter)
+	if err != nil {
+		slog.Error("Error searching by metadata", "err", err)
+		return
+	}
+
+	if len(matches) == 0 {
+		fmt.Println("Nothing found.")
+		return
+	}
+
+	headers := []string{"ID", "Name", "Metadata"}
+	if filter.Select != "" {
+		headers = append(headers, "Selected")
+	}
+
+	rows := make([][]any, 0, len(matches))
+	for _, match := range matches {
+		row := []any{match.ID, match.Name, match.Metadata}
+		if filter.Select != "" {
+			row = append(row, match.Selected)
+		}
+		rows = append(rows, row)
+	}
+
+	tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+}
+
+// Изменение или удаление вложенного ключа metadata
+func (c *Controller) UpdateMetadata(ctx context.Context) {
+	entity, err := readEntity(metadataEntities)
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	id, err := readUUID("Enter record ID: ")
+	if err != nil {
+		slog.Error("Invalid UUID format", "err", err)
+		return
+	}
+
+	key, err := readLine("Enter key path (e.g. social_links.instagram): ")
+	if err != nil || key == "" {
+		slog.Error("Key path is required", "err", err)
+		return
+	}
+
+	input, err := readLine("Enter JSON value (empty to delete the key): ")
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	var value json.RawMessage
+	if input != "" {
+		value = json.RawMessage(input)
+	}
+
+	err = c.storage.UpdateMetadata(ctx, entity, id, strings.Split(key, "."), value)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("Record not found.")
+		return
+	} else if err != nil {
+		slog.Error("Error updating metadata", "err", err)
+		return
+	}
+
+	fmt.Println("Metadata updated.")
+}
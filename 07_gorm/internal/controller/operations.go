@@ -26,6 +26,31 @@ const (
 	ExportUsersToJSON
 	ImportUsersFromJSON
 
+	ArtistDiscography
+	UserLibrary
+
+	ExportEntity
+	ImportEntity
+	ExportSubgraph
+	ImportSubgraph
+
+	ExportDiscographies
+	ImportDiscographies
+
+	FindByMetadata
+	UpdateMetadata
+
+	DeletePlaylist
+	RestoreUser
+	RestorePlaylist
+	Trash
+	PurgeDeleted
+
+	UpdatePlaylist
+	EditHistory
+
+	SearchTracks
+
 	operationsEnd
 	Exit Operation = 0
 )
@@ -58,6 +83,42 @@ func (op Operation) String() string {
 		return "ExportUsersToJSON"
 	case ImportUsersFromJSON:
 		return "ImportUsersFromJSON"
+	case ArtistDiscography:
+		return "ArtistDiscography"
+	case UserLibrary:
+		return "UserLibrary"
+	case ExportEntity:
+		return "ExportEntity"
+	case ImportEntity:
+		return "ImportEntity"
+	case ExportSubgraph:
+		return "ExportSubgraph"
+	case ImportSubgraph:
+		return "ImportSubgraph"
+	case ExportDiscographies:
+		return "ExportDiscographies"
+	case ImportDiscographies:
+		return "ImportDiscographies"
+	case FindByMetadata:
+		return "FindByMetadata"
+	case UpdateMetadata:
+		return "UpdateMetadata"
+	case DeletePlaylist:
+		return "DeletePlaylist"
+	case RestoreUser:
+		return "RestoreUser"
+	case RestorePlaylist:
+		return "RestorePlaylist"
+	case Trash:
+		return "Trash"
+	case PurgeDeleted:
+		return "PurgeDeleted"
+	case UpdatePlaylist:
+		return "UpdatePlaylist"
+	case EditHistory:
+		return "EditHistory"
+	case SearchTracks:
+		return "SearchTracks"
 	case Exit:
 		return "Exit"
 	default:
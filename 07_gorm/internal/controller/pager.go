@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"context"
+
+	tableoutput "github.com/hahaclassic/databases/07_gorm/pkg/table"
+	"github.com/hahaclassic/databases/pkg/pager"
+	"github.com/jedib0t/go-pretty/table"
+)
+
+const pageSize = pager.DefaultSize
+
+// runPager запускает постраничный просмотр с выводом и вводом консоли контроллера
+func runPager[T any](ctx context.Context, p *pager.Pager[T]) {
+	p.Print = func(headers []string, rows [][]interface{}) {
+		tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+	}
+	p.ReadLine = readLine
+	p.Run(ctx)
+}
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	tableoutput "github.com/hahaclassic/databases/07_gorm/pkg/table"
+	"github.com/jedib0t/go-pretty/table"
+)
+
+var trackSortColumns = []string{models.SortByStreamCount, models.SortByName,
+	models.SortByDuration, models.SortByReleaseDate}
+
+// Поиск треков по нескольким условиям (пустой ввод - условие не задано)
+func (c *Controller) SearchTracks(ctx context.Context) {
+	filter, err := readTrackFilter()
+	if err != nil {
+		slog.Error("Invalid search parameters", "err", err)
+		return
+	}
+
+	for {
+		tracks, total, err := c.storage.SearchTracks(ctx, filter)
+		if err != nil {
+			log.Printf("Error searching tracks: %v", err)
+			return
+		}
+
+		if len(tracks) == 0 {
+			fmt.Println("No tracks found.")
+			return
+		}
+
+		headers := []string{"Name", "Artists", "Album", "Year", "Genre", "Duration", "Explicit", "Stream Count"}
+		rows := make([][]any, 0, len(tracks))
+		for _, track := range tracks {
+			album, year := "", 0
+			if track.Album != nil {
+				album, year = track.Album.Title, track.Album.ReleaseDate.Year()
+			}
+			rows = append(rows, []any{track.Name, strings.TrimPrefix(featuring(nil, track.Artists), " by "),
+				album, year, track.Genre, formatDuration(track.Duration), track.Explicit, track.StreamCount})
+		}
+		tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+		fmt.Printf("Tracks %d-%d of %d\n", filter.Offset+1, filter.Offset+len(tracks), total)
+
+		hasPrev, hasNext := filter.Offset > 0, int64(filter.Offset+len(tracks)) < total
+		if !nextOffset(filter, hasPrev, hasNext) {
+			return
+		}
+	}
+}
+
+// nextOffset читает команду перехода по страницам; false - поиск завершен
+func nextOffset(filter *models.TrackFilter, hasPrev, hasNext bool) bool {
+	for {
+		cmd, err := readLine("[n]ext, [p]rev, [q]uit: ")
+		if err != nil {
+			return false
+		}
+
+		switch {
+		case cmd == "n" && hasNext:
+			filter.Offset += filter.Limit
+		case cmd == "p" && hasPrev:
+			filter.Offset = max(filter.Offset-filter.Limit, 0)
+		case cmd == "n" || cmd == "p":
+			fmt.Println("No more pages in this direction.")
+			continue
+		case cmd == "q":
+			return false
+		default:
+			fmt.Println("Unknown command.")
+			continue
+		}
+
+		return true
+	}
+}
+
+func readTrackFilter() (*models.TrackFilter, error) {
+	filter := &models.TrackFilter{Limit: pageSize}
+
+	genres, err := readLine("Genres (comma-separated): ")
+	if err != nil {
+		return nil, err
+	}
+	for _, genre := range strings.Split(genres, ",") {
+		if genre = strings.TrimSpace(genre); genre != "" {
+			filter.Genres = append(filter.Genres, genre)
+		}
+	}
+
+	explicit, err := readLine("Explicit (true/false): ")
+	if err != nil {
+		return nil, err
+	}
+	if explicit != "" {
+		value, err := strconv.ParseBool(explicit)
+		if err != nil {
+			return nil, err
+		}
+		filter.Explicit = &value
+	}
+
+	ranges := []struct {
+		prompt   string
+		min, max *int
+	}{
+		{"Duration in seconds", &filter.MinDuration, &filter.MaxDuration},
+		{"Release year", &filter.MinReleaseYear, &filter.MaxReleaseYear},
+	}
+	for _, r := range ranges {
+		if *r.min, *r.max, err = readRange[int](r.prompt); err != nil {
+			return nil, err
+		}
+	}
+	if filter.MinStreams, filter.MaxStreams, err = readRange[int64]("Stream count"); err != nil {
+		return nil, err
+	}
+
+	if filter.Artist, err = readLine("Artist name contains: "); err != nil {
+		return nil, err
+	}
+	if filter.Album, err = readLine("Album title contains: "); err != nil {
+		return nil, err
+	}
+
+	sortBy, err := readLine(fmt.Sprintf("Sort by (%s; default: %s): ",
+		strings.Join(trackSortColumns, ", "), trackSortColumns[0]))
+	if err != nil {
+		return nil, err
+	}
+	filter.SortBy = trackSortColumns[0]
+	if sortBy != "" {
+		filter.SortBy = sortBy
+	}
+
+	order, err := readLine("Descending? [Y/n]: ")
+	if err != nil {
+		return nil, err
+	}
+	filter.Desc = !strings.EqualFold(order, "n")
+
+	limit, err := readLine(fmt.Sprintf("Page size (default: %d): ", pageSize))
+	if err != nil {
+		return nil, err
+	}
+	if limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			return nil, fmt.Errorf("invalid page size: %q", limit)
+		}
+	}
+
+	return filter, nil
+}
+
+// readRange читает диапазон "min-max", "min-" или "-max" (пустой ввод - без ограничений)
+func readRange[T int | int64](prompt string) (T, T, error) {
+	input, err := readLine(prompt + " range (min-max): ")
+	if err != nil || input == "" {
+		return 0, 0, err
+	}
+
+	from, to, found := strings.Cut(input, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid range: %q", input)
+	}
+
+	var bounds [2]T
+	for i, s := range []string{from, to} {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid range: %q", input)
+		}
+		bounds[i] = T(v)
+	}
+
+	return bounds[0], bounds[1], nil
+}
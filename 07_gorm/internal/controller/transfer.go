@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	tableoutput "github.com/hahaclassic/databases/07_gorm/pkg/table"
+	"github.com/jedib0t/go-pretty/table"
+)
+
+// Выгрузка всех записей одной сущности в JSON или NDJSON
+func (c *Controller) ExportEntity(ctx context.Context) {
+	entity, err := readEntity(models.Entities)
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	format, err := readFormat()
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	fileName := readFileName("export", fmt.Sprintf("./data/%s.%s", entity, format))
+	count, err := exportToFile(fileName, func(f *os.File) (int, error) {
+		return c.storage.ExportEntity(ctx, entity, format, f)
+	})
+	if err != nil {
+		slog.Error("Error exporting records", "entity", entity, "err", err)
+		return
+	}
+
+	fmt.Printf("%d %s successfully exported to '%s'\n", count, entity, fileName)
+}
+
+// Загрузка записей одной сущности (JSON-массив или NDJSON определяется по файлу)
+func (c *Controller) ImportEntity(ctx context.Context) {
+	entity, err := readEntity(models.Entities)
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	fileName := readFileName("import", fmt.Sprintf("./data/%s.%s", entity, models.FormatJSON))
+	f, err := os.Open(fileName)
+	if err != nil {
+		slog.Error("Error opening file", "err", err)
+		return
+	}
+	defer f.Close()
+
+	report, err := c.storage.ImportEntity(ctx, entity, f, confirmMutation)
+	if errors.Is(err, storage.ErrNotConfirmed) {
+		return
+	} else if err != nil {
+		slog.Error("Error importing records", "entity", entity, "err", err)
+		return
+	}
+
+	printImportReport(report)
+}
+
+// Выгрузка исполнителя или пользователя вместе со всеми связанными записями
+func (c *Controller) ExportSubgraph(ctx context.Context) {
+	root, err := readEntity([]models.Entity{models.Artists, models.Users})
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	id, err := readUUID("Enter root ID: ")
+	if err != nil {
+		slog.Error("Invalid UUID format", "err", err)
+		return
+	}
+
+	format, err := readFormat()
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	fileName := readFileName("export", fmt.Sprintf("./data/%s_%s.%s", root, id, format))
+	count, err := exportToFile(fileName, func(f *os.File) (int, error) {
+		return c.storage.ExportSubgraph(ctx, root, id, format, f)
+	})
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("Record not found.")
+		return
+	} else if err != nil {
+		slog.Error("Error exporting subgraph", "err", err)
+		return
+	}
+
+	fmt.Printf("%d records successfully exported to '%s'\n", count, fileName)
+}
+
+// Загрузка выгрузки ExportSubgraph (формат определяется по файлу)
+func (c *Controller) ImportSubgraph(ctx context.Context) {
+	fileName, err := readLine("Enter the file name for import: ")
+	if err != nil || fileName == "" {
+		slog.Error("File name is required", "err", err)
+		return
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		slog.Error("Error opening file", "err", err)
+		return
+	}
+	defer f.Close()
+
+	report, err := c.storage.ImportSubgraph(ctx, f, confirmMutation)
+	if errors.Is(err, storage.ErrNotConfirmed) {
+		return
+	} else if err != nil {
+		slog.Error("Error importing subgraph", "err", err)
+		return
+	}
+
+	printImportReport(report)
+}
+
+func readEntity(entities []models.Entity) (models.Entity, error) {
+	rows := make([][]any, 0, len(entities))
+	for i, entity := range entities {
+		rows = append(rows, []any{i + 1, entity})
+	}
+	tableoutput.PrintTable(table.StyleDefault, []string{"#", "Entity"}, rows)
+
+	input, err := readLine("Enter entity number: ")
+	if err != nil {
+		return "", err
+	}
+
+	i, err := strconv.Atoi(input)
+	if err != nil || i < 1 || i > len(entities) {
+		return "", fmt.Errorf("invalid entity number: %q", input)
+	}
+
+	return entities[i-1], nil
+}
+
+func readFormat() (models.Format, error) {
+	input, err := readLine(fmt.Sprintf("Enter format (%d - %s, %d - %s): ",
+		models.FormatJSON, models.FormatJSON, models.FormatNDJSON, models.FormatNDJSON))
+	if err != nil {
+		return 0, err
+	}
+
+	format, err := strconv.Atoi(input)
+	if err != nil || (models.Format(format) != models.FormatJSON && models.Format(format) != models.FormatNDJSON) {
+		return 0, fmt.Errorf("invalid format: %q", input)
+	}
+
+	return models.Format(format), nil
+}
+
+func readFileName(action, defaultName string) string {
+	fileName, err := readLine(fmt.Sprintf("Enter the file name for %s (default: %s): ", action, defaultName))
+	if err != nil || fileName == "" {
+		return defaultName
+	}
+
+	return fileName
+}
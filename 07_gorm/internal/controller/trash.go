@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	tableoutput "github.com/hahaclassic/databases/07_gorm/pkg/table"
+	"github.com/jedib0t/go-pretty/table"
+)
+
+const (
+	trashLimit = 50
+	// defaultRetentionDays - срок хранения по умолчанию для ручной очистки
+	defaultRetentionDays = 30
+)
+
+func (c *Controller) DeletePlaylist(ctx context.Context) {
+	c.applyByID(ctx, "Enter playlist ID to delete: ", c.storage.DeletePlaylist,
+		"Playlist", "Playlist deleted (it can be restored until purged).")
+}
+
+func (c *Controller) RestoreUser(ctx context.Context) {
+	c.applyByID(ctx, "Enter user ID to restore: ", c.storage.RestoreUser,
+		"Deleted user", "User restored successfully.")
+}
+
+func (c *Controller) RestorePlaylist(ctx context.Context) {
+	c.applyByID(ctx, "Enter playlist ID to restore: ", c.storage.RestorePlaylist,
+		"Deleted playlist", "Playlist restored successfully.")
+}
+
+// applyByID запрашивает ID и выполняет изменение после подтверждения
+func (c *Controller) applyByID(ctx context.Context, prompt string,
+	apply func(context.Context, uuid.UUID, storage.Confirm) (int64, error), subject, done string) {
+	id, err := readUUID(prompt)
+	if err != nil {
+		slog.Error("Invalid UUID format", "err", err)
+		return
+	}
+
+	_, err = apply(ctx, id, confirmMutation)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Printf("%s not found.\n", subject)
+		return
+	} else if errors.Is(err, storage.ErrNotConfirmed) {
+		return
+	} else if err != nil {
+		log.Printf("Error: %v", err)
+		return
+	}
+
+	fmt.Println(done)
+}
+
+// Мягко удаленные пользователи и плейлисты
+func (c *Controller) Trash(ctx context.Context) {
+	records, err := c.storage.Trash(ctx, trashLimit)
+	if err != nil {
+		log.Printf("Error getting deleted records: %v", err)
+		return
+	}
+
+	if len(records) == 0 {
+		fmt.Println("Trash is empty.")
+		return
+	}
+
+	headers := []string{"Entity", "ID", "Name", "Deleted At"}
+	rows := [][]any{}
+	for _, r := range records {
+		rows = append(rows, []any{r.Entity, r.ID, r.Name, r.DeletedAt.Format(time.DateTime)})
+	}
+
+	tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+}
+
+// Ручной запуск окончательного удаления (то же делает фоновая очистка)
+func (c *Controller) PurgeDeleted(ctx context.Context) {
+	input, err := readLine(fmt.Sprintf("Purge records deleted more than N days ago (default: %d): ",
+		defaultRetentionDays))
+	if err != nil {
+		slog.Error("[ERR]", "err", err)
+		return
+	}
+
+	days := defaultRetentionDays
+	if input != "" {
+		if days, err = strconv.Atoi(input); err != nil || days < 0 {
+			slog.Error("Invalid number of days", "days", input)
+			return
+		}
+	}
+
+	affected, err := c.storage.PurgeDeleted(ctx, time.Now().AddDate(0, 0, -days), confirmMutation)
+	if errors.Is(err, storage.ErrNotConfirmed) {
+		return
+	} else if err != nil {
+		log.Printf("Error purging deleted records: %v", err)
+		return
+	}
+
+	fmt.Printf("%d deleted records purged.\n", affected)
+}
@@ -7,9 +7,19 @@ import (
 )
 
 type Album struct {
-	ID          uuid.UUID `fake:"-"`
-	Title       string    `fake:"{sentence:3}"`
-	ReleaseDate time.Time `fake:"-"`
-	Label       string    `fake:"{sentence:1}"`
-	Genre       string    `fake:"-"`
+	ID          uuid.UUID `fake:"-" json:"id"`
+	Title       string    `fake:"{sentence:3}" json:"title"`
+	ReleaseDate time.Time `fake:"-" json:"release_date"`
+	Label       string    `fake:"{sentence:1}" json:"label"`
+	Genre       string    `fake:"-" json:"genre"`
+
+	Metadata Metadata `fake:"-" json:"metadata"`
+
+	Tracks  []*Track  `json:"tracks,omitempty"`
+	Artists []*Artist `gorm:"many2many:albums_by_artists" json:"artists,omitempty"`
+}
+
+// Validate проверяет известные поля metadata
+func (a *Album) Validate() error {
+	return ValidateMetadata(Albums, a.Metadata)
 }
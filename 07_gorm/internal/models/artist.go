@@ -3,9 +3,39 @@ package models
 import "github.com/google/uuid"
 
 type Artist struct {
-	ID        uuid.UUID `fake:"-"`
-	Name      string    `fake:"-"`
-	Genre     string    `fake:"-"`
-	Country   string    `fake:"-"`
-	DebutYear int       `fake:"-"`
+	ID        uuid.UUID `fake:"-" json:"id"`
+	Name      string    `fake:"-" json:"name"`
+	Genre     string    `fake:"-" json:"genre"`
+	Country   string    `fake:"-" json:"country"`
+	DebutYear int       `fake:"-" json:"debut_year"`
+
+	Metadata Metadata `fake:"-" json:"metadata"`
+
+	Albums []*Album `gorm:"many2many:albums_by_artists" json:"albums,omitempty"`
+	Tracks []*Track `gorm:"many2many:tracks_by_artists" json:"tracks,omitempty"`
+}
+
+// Validate проверяет известные поля metadata
+func (a *Artist) Validate() error {
+	return ValidateMetadata(Artists, a.Metadata)
+}
+
+// AlbumArtist - связь альбома и исполнителя (albums_by_artists)
+type AlbumArtist struct {
+	AlbumID  uuid.UUID `gorm:"primaryKey" json:"album_id"`
+	ArtistID uuid.UUID `gorm:"primaryKey" json:"artist_id"`
+}
+
+func (AlbumArtist) TableName() string {
+	return "albums_by_artists"
+}
+
+// TrackArtist - связь трека и исполнителя (tracks_by_artists)
+type TrackArtist struct {
+	TrackID  uuid.UUID `gorm:"primaryKey" json:"track_id"`
+	ArtistID uuid.UUID `gorm:"primaryKey" json:"artist_id"`
+}
+
+func (TrackArtist) TableName() string {
+	return "tracks_by_artists"
 }
@@ -0,0 +1,48 @@
+package models
+
+import "github.com/google/uuid"
+
+// DiscographyDocument - исполнитель со всеми альбомами и треками одним документом.
+// Collaborators - все остальные исполнители, упомянутые в документе
+// (альбомы и треки ссылаются на исполнителей по ArtistRef).
+type DiscographyDocument struct {
+	ID            uuid.UUID        `json:"id"`
+	Name          string           `json:"name"`
+	Genre         string           `json:"genre"`
+	Country       string           `json:"country"`
+	DebutYear     int              `json:"debut_year"`
+	StreamCount   int64            `json:"stream_count"`
+	Metadata      Metadata         `json:"metadata"`
+	Albums        []*AlbumDocument `json:"albums"`
+	Collaborators []*Artist        `json:"collaborators"`
+}
+
+type AlbumDocument struct {
+	ID          uuid.UUID        `json:"id"`
+	Title       string           `json:"title"`
+	ReleaseDate JsonDate         `json:"release_date"`
+	Label       string           `json:"label"`
+	Genre       string           `json:"genre"`
+	StreamCount int64            `json:"stream_count"`
+	Metadata    Metadata         `json:"metadata"`
+	Artists     []*ArtistRef     `json:"artists"`
+	Tracks      []*TrackDocument `json:"tracks"`
+}
+
+// TrackDocument - трек альбома (треки документа упорядочены по OrderInAlbum)
+type TrackDocument struct {
+	ID           uuid.UUID    `json:"id"`
+	Name         string       `json:"name"`
+	OrderInAlbum int          `json:"order_in_album"`
+	Explicit     bool         `json:"explicit"`
+	Duration     int          `json:"duration"`
+	Genre        string       `json:"genre"`
+	StreamCount  int64        `json:"stream_count"`
+	Metadata     Metadata     `json:"metadata"`
+	Artists      []*ArtistRef `json:"artists"`
+}
+
+type ArtistRef struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Edit - изменение одного поля записи (edit_history); Version - версия записи
+// после изменения
+type Edit struct {
+	ID       int64     `json:"id"`
+	Entity   Entity    `json:"entity"`
+	RecordID uuid.UUID `json:"record_id"`
+	Field    string    `json:"field"`
+	OldValue string    `json:"old_value"`
+	NewValue string    `json:"new_value"`
+	Version  int64     `json:"version"`
+	EditedAt time.Time `gorm:"default:clock_timestamp()" json:"edited_at"`
+	DBUser   string    `gorm:"column:db_user;default:(-)" json:"db_user"`
+}
+
+func (Edit) TableName() string {
+	return "edit_history"
+}
+
+// PlaylistUpdate - изменяемые поля плейлиста (nil - поле не меняется)
+type PlaylistUpdate struct {
+	Title       *string
+	Description *string
+	Private     *bool
+}
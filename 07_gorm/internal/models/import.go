@@ -0,0 +1,16 @@
+package models
+
+// RecordError - ошибка импорта одной записи
+// (Index - позиция среди записей сущности во входных данных)
+type RecordError struct {
+	Entity Entity
+	Index  int
+	Key    string
+	Err    error
+}
+
+type ImportReport struct {
+	Total    int
+	Imported int
+	Errors   []*RecordError
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+var ErrInvalidMetadata = errors.New("invalid metadata")
+
+// Metadata - столбец metadata (JSONB) в исходном виде: ключи вне известных полей
+// (ArtistMetadata, AlbumMetadata, TrackMetadata) не теряются при чтении, записи,
+// выгрузке и загрузке. Известные поля разбираются по запросу (DecodeMetadata)
+// и проверяются при загрузке и изменении (ValidateMetadata).
+type Metadata datatypes.JSON
+
+// Value - пустые метаданные сохраняются как {} (столбец NOT NULL)
+func (m Metadata) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return "{}", nil
+	}
+
+	return datatypes.JSON(m).Value()
+}
+
+func (m *Metadata) Scan(value any) error {
+	return (*datatypes.JSON)(m).Scan(value)
+}
+
+func (m Metadata) MarshalJSON() ([]byte, error) {
+	if len(m) == 0 {
+		return []byte("{}"), nil
+	}
+
+	return datatypes.JSON(m).MarshalJSON()
+}
+
+func (m *Metadata) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*m = nil
+		return nil
+	}
+
+	return (*datatypes.JSON)(m).UnmarshalJSON(b)
+}
+
+// DecodeMetadata разбирает известные поля метаданных
+func DecodeMetadata[T ArtistMetadata | AlbumMetadata | TrackMetadata](m Metadata) (T, error) {
+	var fields T
+	if len(m) == 0 {
+		return fields, nil
+	}
+
+	err := json.Unmarshal(m, &fields)
+	return fields, err
+}
+
+// ValidateMetadata проверяет, что metadata сущности - JSON-объект, а известные
+// поля имеют ожидаемые типы (остальные ключи не проверяются)
+func ValidateMetadata(entity Entity, m Metadata) error {
+	var err error
+	switch entity {
+	case Artists:
+		_, err = DecodeMetadata[ArtistMetadata](m)
+	case Albums:
+		_, err = DecodeMetadata[AlbumMetadata](m)
+	case Tracks:
+		_, err = DecodeMetadata[TrackMetadata](m)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMetadata, err)
+	}
+
+	return nil
+}
+
+// Credit - участник записи и его роль (producer, mixing, vocals, ...)
+type Credit struct {
+	Role string `json:"role"`
+	Name string `json:"name"`
+}
+
+// ArtistMetadata - содержимое artists.metadata (JSONB)
+type ArtistMetadata struct {
+	// SocialLinks - ссылки по названию сети (instagram, youtube, ...)
+	SocialLinks map[string]string `json:"social_links,omitempty"`
+}
+
+// AlbumMetadata - содержимое albums.metadata (JSONB)
+type AlbumMetadata struct {
+	Credits  []Credit `json:"credits,omitempty"`
+	MoodTags []string `json:"mood_tags,omitempty"`
+}
+
+// TrackMetadata - содержимое tracks.metadata (JSONB)
+type TrackMetadata struct {
+	BPM      int      `json:"bpm,omitempty"`
+	Credits  []Credit `json:"credits,omitempty"`
+	MoodTags []string `json:"mood_tags,omitempty"`
+}
+
+// MetadataFilter - условия поиска по metadata: Contains - JSON-документ для @>,
+// Predicate - jsonpath-предикат для @@ (например, $.bpm > 120),
+// Select - jsonpath, значения которого выводятся для найденных записей
+type MetadataFilter struct {
+	Entity    Entity
+	Contains  string
+	Predicate string
+	Select    string
+	Limit     int
+}
+
+type MetadataMatch struct {
+	ID       uuid.UUID
+	Name     string
+	Metadata string
+	Selected string
+}
@@ -0,0 +1,12 @@
+package models
+
+// Ключи столбцов сортировки, допустимые в pager.Query.SortBy
+const (
+	SortByName              = "name"
+	SortByDuration          = "duration"
+	SortByStreamCount       = "stream_count"
+	SortByReleaseDate       = "release_date"
+	SortByBirthDate         = "birth_date"
+	SortByRegistrationDate  = "registration_date"
+	SortByPremiumExpiration = "premium_expiration"
+)
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AccessLevel int
+
+const (
+	Owner AccessLevel = iota + 1
+	Other
+)
+
+type Playlist struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Private     bool      `json:"private"`
+	LastUpdated time.Time `json:"last_updated"`
+	Rating      int       `json:"rating"`
+	// Version - версия записи для оптимистической блокировки
+	Version int64 `gorm:"default:1" json:"version"`
+	// DeletedAt - время мягкого удаления (запросы GORM исключают такие строки)
+	DeletedAt gorm.DeletedAt `json:"deleted_at"`
+
+	// Tracks - треки плейлиста в порядке track_order
+	Tracks []*PlaylistTrack `json:"tracks,omitempty"`
+	Users  []*UserPlaylist  `json:"users,omitempty"`
+}
+
+// PlaylistTrack - трек в плейлисте (playlist_tracks)
+type PlaylistTrack struct {
+	PlaylistID uuid.UUID `gorm:"primaryKey" json:"playlist_id"`
+	TrackID    uuid.UUID `gorm:"primaryKey" json:"track_id"`
+	DateAdded  time.Time `json:"date_added"`
+	TrackOrder int       `json:"track_order"`
+
+	Track *Track `json:"track,omitempty"`
+}
+
+// UserPlaylist - плейлист в библиотеке пользователя (user_playlists)
+type UserPlaylist struct {
+	PlaylistID  uuid.UUID   `gorm:"primaryKey" json:"playlist_id"`
+	UserID      uuid.UUID   `gorm:"primaryKey" json:"user_id"`
+	IsFavorite  bool        `json:"is_favorite"`
+	AccessLevel AccessLevel `json:"access_level"`
+
+	Playlist *Playlist `json:"playlist,omitempty"`
+}
@@ -3,12 +3,22 @@ package models
 import "github.com/google/uuid"
 
 type Track struct {
-	ID           uuid.UUID `gorm:"primaryKey"`
-	Name         string
-	OrderInAlbum int
-	AlbumID      uuid.UUID
-	Explicit     bool
-	Duration     int
-	Genre        string
-	StreamCount  int64
+	ID           uuid.UUID `gorm:"primaryKey" json:"id"`
+	Name         string    `json:"name"`
+	OrderInAlbum int       `json:"order_in_album"`
+	AlbumID      uuid.UUID `json:"album_id"`
+	Explicit     bool      `json:"explicit"`
+	Duration     int       `json:"duration"`
+	Genre        string    `json:"genre"`
+	StreamCount  int64     `json:"stream_count"`
+
+	Metadata Metadata `json:"metadata"`
+
+	Album   *Album    `json:"album,omitempty"`
+	Artists []*Artist `gorm:"many2many:tracks_by_artists" json:"artists,omitempty"`
+}
+
+// Validate проверяет известные поля metadata
+func (t *Track) Validate() error {
+	return ValidateMetadata(Tracks, t.Metadata)
 }
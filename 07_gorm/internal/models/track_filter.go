@@ -0,0 +1,22 @@
+package models
+
+// TrackFilter - условия поиска треков; нулевые значения не ограничивают выборку
+type TrackFilter struct {
+	Genres   []string
+	Explicit *bool
+
+	// диапазоны включительно (длительность - в секундах)
+	MinDuration, MaxDuration       int
+	MinStreams, MaxStreams         int64
+	MinReleaseYear, MaxReleaseYear int
+
+	// Artist, Album - подстроки имени исполнителя и названия альбома
+	Artist string
+	Album  string
+
+	// SortBy - SortByStreamCount, SortByName, SortByDuration или SortByReleaseDate
+	SortBy string
+	Desc   bool
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,56 @@
+package models
+
+// Entity - таблица, которую можно выгрузить и загрузить (см. Export/Import в storage)
+type Entity string
+
+const (
+	Artists        Entity = "artists"
+	Albums         Entity = "albums"
+	Tracks         Entity = "tracks"
+	AlbumArtists   Entity = "albums_by_artists"
+	TrackArtists   Entity = "tracks_by_artists"
+	Users          Entity = "users"
+	Playlists      Entity = "playlists"
+	UserPlaylists  Entity = "user_playlists"
+	PlaylistTracks Entity = "playlist_tracks"
+)
+
+// Entities - все сущности в порядке зависимостей по внешним ключам
+var Entities = []Entity{
+	Artists, Albums, Tracks, AlbumArtists, TrackArtists,
+	Users, Playlists, UserPlaylists, PlaylistTracks,
+}
+
+type Format int
+
+const (
+	// FormatJSON - JSON-массив записей (для подграфа - объект {сущность: [записи]})
+	FormatJSON Format = iota + 1
+	// FormatNDJSON - по записи на строку (для подграфа - {"entity": ..., "record": ...})
+	FormatNDJSON
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatJSON:
+		return "json"
+	case FormatNDJSON:
+		return "ndjson"
+	default:
+		return "unknown"
+	}
+}
+
+// Subgraph - связанные записи нескольких сущностей, выгружаемые вместе
+// (исполнитель со всеми альбомами и треками или пользователь с плейлистами)
+type Subgraph struct {
+	Artists        []*Artist        `json:"artists"`
+	Albums         []*Album         `json:"albums"`
+	Tracks         []*Track         `json:"tracks"`
+	AlbumArtists   []*AlbumArtist   `json:"albums_by_artists"`
+	TrackArtists   []*TrackArtist   `json:"tracks_by_artists"`
+	Users          []*User          `json:"users"`
+	Playlists      []*Playlist      `json:"playlists"`
+	UserPlaylists  []*UserPlaylist  `json:"user_playlists"`
+	PlaylistTracks []*PlaylistTrack `json:"playlist_tracks"`
+}
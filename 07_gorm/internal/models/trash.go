@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeletedRecord - мягко удаленная запись, которую еще можно восстановить
+type DeletedRecord struct {
+	Entity    Entity
+	ID        uuid.UUID
+	Name      string
+	DeletedAt time.Time
+}
@@ -1,39 +1,155 @@
 package models
 
 import (
+	"database/sql/driver"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// timestamp без часового пояса (так выгружается TIMESTAMP через row_to_json)
+	timestampLayout = "2006-01-02T15:04:05.999999999"
+
+	maxUserNameLength = 100
+	// minRegistrationAge - ограничение check_registration_date
+	minRegistrationAge = 12
+)
+
+var (
+	ErrInvalidUserID           = errors.New("user id is empty")
+	ErrInvalidUserName         = errors.New("user name must be 1-100 characters long")
+	ErrInvalidBirthDate        = errors.New("birth date must be after 1900-01-01")
+	ErrInvalidRegistrationDate = errors.New("registration date must be at least 12 years after birth date")
+
+	// minBirthDate - ограничение check_birth_date
+	minBirthDate = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
 )
 
 type User struct {
-	ID                uuid.UUID     `json:"id"`
-	Name              string        `json:"name"`
-	RegistrationDate  time.Time     `json:"registration_date"`
-	BirthDate         JsonBirthDate `json:"birth_date"`
-	Premium           bool          `json:"premium"`
-	PremiumExpiration time.Time     `json:"premium_expiration"`
+	ID                uuid.UUID `json:"id"`
+	Name              string    `json:"name"`
+	RegistrationDate  time.Time `json:"registration_date"`
+	BirthDate         JsonDate  `json:"birth_date"`
+	Premium           bool      `json:"premium"`
+	PremiumExpiration time.Time `json:"premium_expiration"`
+	// Version - версия записи для оптимистической блокировки
+	Version int64 `gorm:"default:1" json:"version"`
+	// DeletedAt - время мягкого удаления (запросы GORM исключают такие строки)
+	DeletedAt gorm.DeletedAt `json:"deleted_at"`
+
+	Playlists []*UserPlaylist `json:"playlists,omitempty"`
+}
+
+// Validate проверяет ограничения таблицы users
+func (u *User) Validate() error {
+	var errs []error
+
+	if u.ID == uuid.Nil {
+		errs = append(errs, ErrInvalidUserID)
+	}
+	if n := utf8.RuneCountInString(u.Name); n == 0 || n > maxUserNameLength {
+		errs = append(errs, ErrInvalidUserName)
+	}
+
+	birthDate := time.Time(u.BirthDate)
+	if !birthDate.After(minBirthDate) {
+		errs = append(errs, ErrInvalidBirthDate)
+	}
+	if u.RegistrationDate.Before(birthDate.AddDate(minRegistrationAge, 0, 0)) {
+		errs = append(errs, ErrInvalidRegistrationDate)
+	}
+
+	return errors.Join(errs...)
+}
+
+// UnmarshalJSON принимает даты регистрации и окончания премиума как
+// в RFC 3339, так и без часового пояса (считаются UTC)
+func (u *User) UnmarshalJSON(b []byte) error {
+	type user User
+	aux := struct {
+		*user
+		RegistrationDate  jsonTimestamp `json:"registration_date"`
+		PremiumExpiration jsonTimestamp `json:"premium_expiration"`
+	}{user: (*user)(u)}
+
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+
+	u.RegistrationDate = time.Time(aux.RegistrationDate)
+	u.PremiumExpiration = time.Time(aux.PremiumExpiration)
+
+	return nil
+}
+
+type jsonTimestamp time.Time
+
+func (j *jsonTimestamp) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "null" || s == "" {
+		*j = jsonTimestamp{}
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		if t, err = time.Parse(timestampLayout, s); err != nil {
+			return err
+		}
+	}
+	*j = jsonTimestamp(t)
+
+	return nil
 }
 
-type JsonBirthDate time.Time
+// JsonDate - дата (без времени) в JSON в формате YYYY-MM-DD
+type JsonDate time.Time
 
-func (j *JsonBirthDate) UnmarshalJSON(b []byte) error {
+func (j *JsonDate) UnmarshalJSON(b []byte) error {
 	s := strings.Trim(string(b), `"`)
-	t, err := time.Parse("2006-01-02", s)
+	if len(s) > len(dateLayout) {
+		s = s[:len(dateLayout)]
+	}
+
+	t, err := time.Parse(dateLayout, s)
 	if err != nil {
 		return err
 	}
-	*j = JsonBirthDate(t)
+	*j = JsonDate(t)
 	return nil
 }
 
-func (j JsonBirthDate) MarshalJSON() ([]byte, error) {
-	return json.Marshal(time.Time(j))
+func (j JsonDate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Format(dateLayout))
 }
 
-func (j JsonBirthDate) Format(s string) string {
+func (j JsonDate) Format(s string) string {
 	t := time.Time(j)
 	return t.Format(s)
 }
+
+func (j *JsonDate) Scan(value any) error {
+	if value == nil {
+		*j = JsonDate{}
+		return nil
+	}
+
+	t, ok := value.(time.Time)
+	if !ok {
+		return fmt.Errorf("unsupported date type %T", value)
+	}
+	*j = JsonDate(t)
+	return nil
+}
+
+func (j JsonDate) Value() (driver.Value, error) {
+	return time.Time(j), nil
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	"gorm.io/gorm"
+)
+
+// Получение исполнителя с альбомами (по дате релиза) и их треками (по порядку в альбоме),
+// у каждого трека - все его исполнители
+func (s *Storage) ArtistDiscography(ctx context.Context, artistID uuid.UUID) (*models.Artist, error) {
+	var artist models.Artist
+
+	err := s.db.WithContext(ctx).
+		Preload("Albums", func(db *gorm.DB) *gorm.DB {
+			return db.Order("albums.release_date, albums.title")
+		}).
+		Preload("Albums.Tracks", func(db *gorm.DB) *gorm.DB {
+			return db.Order("tracks.order_in_album")
+		}).
+		Preload("Albums.Tracks.Artists").
+		Where("id = ?", artistID).
+		First(&artist).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %w", storage.ErrArtistDiscography, storage.ErrNotFound)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrArtistDiscography, err)
+	}
+
+	return &artist, nil
+}
+
+// Получение пользователя с плейлистами (избранные первыми) и их треками (по track_order),
+// у каждого трека - альбом и исполнители
+func (s *Storage) UserLibrary(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	var user models.User
+
+	err := s.db.WithContext(ctx).
+		Preload("Playlists", func(db *gorm.DB) *gorm.DB {
+			return db.Order("user_playlists.is_favorite DESC")
+		}).
+		Preload("Playlists.Playlist.Tracks", func(db *gorm.DB) *gorm.DB {
+			return db.Order("playlist_tracks.track_order")
+		}).
+		Preload("Playlists.Playlist.Tracks.Track.Album").
+		Preload("Playlists.Playlist.Tracks.Track.Artists").
+		Where("id = ?", userID).
+		First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %w", storage.ErrUserLibrary, storage.ErrNotFound)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrUserLibrary, err)
+	}
+
+	return &user, nil
+}
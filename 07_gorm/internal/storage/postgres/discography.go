@@ -0,0 +1,182 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+)
+
+// discographySelect строит документ исполнителя ar целиком на стороне Postgres
+const discographySelect = `json_build_object(
+	'id', ar.id, 'name', ar.name, 'genre', ar.genre, 'country', ar.country, 'debut_year', ar.debut_year,
+	'metadata', ar.metadata,
+	'stream_count', COALESCE((SELECT sum(t.stream_count) FROM tracks t
+		JOIN albums_by_artists aa ON aa.album_id = t.album_id WHERE aa.artist_id = ar.id), 0),
+	'albums', COALESCE((SELECT json_agg(json_build_object(
+			'id', al.id, 'title', al.title, 'release_date', al.release_date, 'label', al.label, 'genre', al.genre,
+			'metadata', al.metadata,
+			'stream_count', COALESCE((SELECT sum(t.stream_count) FROM tracks t WHERE t.album_id = al.id), 0),
+			'artists', COALESCE((SELECT json_agg(json_build_object('id', co.id, 'name', co.name) ORDER BY co.name)
+				FROM albums_by_artists aa2 JOIN artists co ON co.id = aa2.artist_id
+				WHERE aa2.album_id = al.id), '[]'),
+			'tracks', COALESCE((SELECT json_agg(json_build_object(
+					'id', t.id, 'name', t.name, 'order_in_album', t.order_in_album, 'explicit', t.explicit,
+					'duration', t.duration, 'genre', t.genre, 'stream_count', t.stream_count,
+					'metadata', t.metadata,
+					'artists', COALESCE((SELECT json_agg(json_build_object('id', co.id, 'name', co.name) ORDER BY co.name)
+						FROM tracks_by_artists ta JOIN artists co ON co.id = ta.artist_id
+						WHERE ta.track_id = t.id), '[]'))
+				ORDER BY t.order_in_album)
+				FROM tracks t WHERE t.album_id = al.id), '[]'))
+		ORDER BY al.release_date, al.title)
+		FROM albums al JOIN albums_by_artists aa ON aa.album_id = al.id
+		WHERE aa.artist_id = ar.id), '[]'),
+	'collaborators', COALESCE((SELECT json_agg(to_json(co) ORDER BY co.name) FROM artists co
+		WHERE co.id <> ar.id AND co.id IN (
+			SELECT aa2.artist_id FROM albums_by_artists aa
+			JOIN albums_by_artists aa2 ON aa2.album_id = aa.album_id
+			WHERE aa.artist_id = ar.id
+			UNION
+			SELECT ta.artist_id FROM albums_by_artists aa
+			JOIN tracks t ON t.album_id = aa.album_id
+			JOIN tracks_by_artists ta ON ta.track_id = t.id
+			WHERE aa.artist_id = ar.id)), '[]')
+) AS document`
+
+// Построение документов дискографии (artistID = uuid.Nil - всех исполнителей по имени);
+// каждый документ передается в fn, не накапливаясь в памяти
+func (s *Storage) Discographies(ctx context.Context, artistID uuid.UUID,
+	fn func(*models.DiscographyDocument) error) (count int, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%w: %w", storage.ErrDiscographies, err)
+		}
+	}()
+
+	query := s.db.WithContext(ctx).Table("artists ar").Select(discographySelect).Order("ar.name, ar.id")
+	if artistID != uuid.Nil {
+		query = query.Where("ar.id = ?", artistID)
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return count, err
+		}
+
+		var document models.DiscographyDocument
+		if err := json.Unmarshal(raw, &document); err != nil {
+			return count, err
+		}
+		if err := fn(&document); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+
+	if count == 0 && artistID != uuid.Nil {
+		return 0, storage.ErrNotFound
+	}
+
+	return count, nil
+}
+
+// Восстановление исполнителей, альбомов, треков и их связей из документов дискографии
+func (s *Storage) ImportDiscographies(ctx context.Context, documents []*models.DiscographyDocument,
+	confirm storage.Confirm) (*models.ImportReport, error) {
+	records, err := subgraphRaw(flattenDiscographies(documents))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrImport, err)
+	}
+
+	return s.importRecords(ctx, "ImportDiscographies", records, confirm)
+}
+
+// flattenDiscographies раскладывает документы по строкам таблиц без повторов
+func flattenDiscographies(documents []*models.DiscographyDocument) *models.Subgraph {
+	graph := &models.Subgraph{}
+	seen := make(map[string]bool)
+	add := func(entity models.Entity, key string) bool {
+		key = string(entity) + "/" + key
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+		return true
+	}
+
+	addArtist := func(artist *models.Artist) {
+		if add(models.Artists, artist.ID.String()) {
+			graph.Artists = append(graph.Artists, artist)
+		}
+	}
+
+	for _, doc := range documents {
+		addArtist(&models.Artist{ID: doc.ID, Name: doc.Name, Genre: doc.Genre,
+			Country: doc.Country, DebutYear: doc.DebutYear, Metadata: doc.Metadata})
+		for _, artist := range doc.Collaborators {
+			addArtist(artist)
+		}
+
+		for _, album := range doc.Albums {
+			if add(models.Albums, album.ID.String()) {
+				graph.Albums = append(graph.Albums, &models.Album{ID: album.ID, Title: album.Title,
+					ReleaseDate: time.Time(album.ReleaseDate), Label: album.Label, Genre: album.Genre,
+					Metadata: album.Metadata})
+			}
+			for _, artist := range album.Artists {
+				if add(models.AlbumArtists, album.ID.String()+"/"+artist.ID.String()) {
+					graph.AlbumArtists = append(graph.AlbumArtists,
+						&models.AlbumArtist{AlbumID: album.ID, ArtistID: artist.ID})
+				}
+			}
+
+			for _, track := range album.Tracks {
+				if add(models.Tracks, track.ID.String()) {
+					graph.Tracks = append(graph.Tracks, &models.Track{ID: track.ID, Name: track.Name,
+						OrderInAlbum: track.OrderInAlbum, AlbumID: album.ID, Explicit: track.Explicit,
+						Duration: track.Duration, Genre: track.Genre, StreamCount: track.StreamCount,
+						Metadata: track.Metadata})
+				}
+				for _, artist := range track.Artists {
+					if add(models.TrackArtists, track.ID.String()+"/"+artist.ID.String()) {
+						graph.TrackArtists = append(graph.TrackArtists,
+							&models.TrackArtist{TrackID: track.ID, ArtistID: artist.ID})
+					}
+				}
+			}
+		}
+	}
+
+	return graph
+}
+
+// subgraphRaw - записи подграфа в виде JSON для importRecords
+func subgraphRaw(graph *models.Subgraph) (map[models.Entity][]json.RawMessage, error) {
+	records := make(map[models.Entity][]json.RawMessage)
+	for _, entity := range models.Entities {
+		for _, record := range subgraphRecords(graph, entity) {
+			raw, err := json.Marshal(record)
+			if err != nil {
+				return nil, err
+			}
+			records[entity] = append(records[entity], raw)
+		}
+	}
+
+	return records, nil
+}
@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hahaclassic/databases/07_gorm/config"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	// explainQueueSize - сколько медленных запросов может ждать EXPLAIN
+	// (при переполнении планы пропускаются, а не тормозят основной поток)
+	explainQueueSize = 64
+	explainTimeout   = 30 * time.Second
+)
+
+var errInvalidLogLevel = errors.New("invalid sql log level")
+
+var logLevels = map[string]logger.LogLevel{
+	"silent": logger.Silent,
+	"error":  logger.Error,
+	"warn":   logger.Warn,
+	"info":   logger.Info,
+}
+
+// slowQuery - медленный запрос, ожидающий EXPLAIN
+type slowQuery struct {
+	sql      string
+	elapsed  time.Duration
+	executed time.Time
+}
+
+// sqlLogger пишет запросы с длительностью через стандартный логгер GORM и
+// собирает планы медленных SELECT-запросов
+type sqlLogger struct {
+	logger.Interface
+
+	threshold time.Duration
+	// slow - nil, если планы не собираются
+	slow chan<- slowQuery
+
+	// stopExplain останавливает сбор планов, explainDone закрывается после
+	// его завершения; files - открытые логгером файлы
+	stopExplain context.CancelFunc
+	explainDone chan struct{}
+	files       []io.Closer
+}
+
+// newSQLLogger создает логгер по конфигурации; explain запускается после
+// открытия соединения (ему нужен *sql.DB)
+func newSQLLogger(cfg *config.SQLLogConfig) (*sqlLogger, func(db *sql.DB), error) {
+	level, ok := logLevels[strings.ToLower(cfg.Level)]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %q", errInvalidLogLevel, cfg.Level)
+	}
+
+	l := &sqlLogger{threshold: cfg.SlowThreshold}
+
+	var out io.Writer = os.Stderr
+	if cfg.File != "" {
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = f
+		l.files = append(l.files, f)
+	}
+
+	l.Interface = logger.New(log.New(out, "\n", log.LstdFlags), logger.Config{
+		SlowThreshold:             cfg.SlowThreshold,
+		LogLevel:                  level,
+		IgnoreRecordNotFoundError: true,
+	})
+
+	if cfg.ExplainFile == "" {
+		return l, func(*sql.DB) {}, nil
+	}
+
+	plans, err := os.OpenFile(cfg.ExplainFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, errors.Join(err, l.Close())
+	}
+	l.files = append(l.files, plans)
+
+	slow := make(chan slowQuery, explainQueueSize)
+	l.slow = slow
+
+	ctx, stop := context.WithCancel(context.Background())
+	l.stopExplain, l.explainDone = stop, make(chan struct{})
+
+	return l, func(db *sql.DB) {
+		go func() {
+			defer close(l.explainDone)
+			explainSlowQueries(ctx, db, slow, plans)
+		}()
+	}, nil
+}
+
+// Close останавливает сбор планов (прерывая текущий EXPLAIN) и закрывает файлы логов
+func (l *sqlLogger) Close() error {
+	if l.stopExplain != nil {
+		l.stopExplain()
+		<-l.explainDone
+	}
+
+	var errs []error
+	for _, f := range l.files {
+		errs = append(errs, f.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func (l *sqlLogger) LogMode(level logger.LogLevel) logger.Interface {
+	c := *l
+	c.Interface = l.Interface.LogMode(level)
+
+	return &c
+}
+
+func (l *sqlLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	l.Interface.Trace(ctx, begin, fc, err)
+
+	elapsed := time.Since(begin)
+	if l.slow == nil || err != nil || l.threshold <= 0 || elapsed < l.threshold {
+		return
+	}
+
+	query, _ := fc()
+	if !explainable(query) {
+		return
+	}
+
+	select {
+	case l.slow <- slowQuery{sql: query, elapsed: elapsed, executed: begin}:
+	default:
+	}
+}
+
+// explainable - можно ли безопасно повторить запрос под EXPLAIN ANALYZE в отдельном
+// соединении: только чтение и без блокировок строк (иначе EXPLAIN ждал бы
+// транзакцию, которая ждет логгер)
+func explainable(query string) bool {
+	q := strings.ToUpper(strings.TrimSpace(query))
+
+	return strings.HasPrefix(q, "SELECT") &&
+		!strings.Contains(q, " FOR UPDATE") && !strings.Contains(q, " FOR SHARE") &&
+		!strings.Contains(q, " FOR NO KEY UPDATE") && !strings.Contains(q, " FOR KEY SHARE")
+}
+
+// explainSlowQueries выполняет EXPLAIN (ANALYZE, BUFFERS) для медленных запросов
+// напрямую через database/sql (в обход GORM и этого логгера) и пишет планы в w
+func explainSlowQueries(ctx context.Context, db *sql.DB, slow <-chan slowQuery, w io.Writer) {
+	for {
+		var q slowQuery
+		select {
+		case <-ctx.Done():
+			return
+		case q = <-slow:
+		}
+
+		plan, err := explain(ctx, db, q.sql)
+		if errors.Is(err, context.Canceled) {
+			return
+		} else if err != nil {
+			slog.Warn("EXPLAIN failed", "err", err)
+			continue
+		}
+
+		_, err = fmt.Fprintf(w, "-- %s, %s\n%s;\n%s\n\n", q.executed.Format(time.RFC3339),
+			q.elapsed.Round(time.Microsecond), q.sql, plan)
+		if err != nil {
+			slog.Warn("failed to write query plan", "err", err)
+		}
+	}
+}
+
+func explain(ctx context.Context, db *sql.DB, query string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, explainTimeout)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, "EXPLAIN (ANALYZE, BUFFERS) "+query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		plan = append(plan, line)
+	}
+
+	return strings.Join(plan, "\n"), rows.Err()
+}
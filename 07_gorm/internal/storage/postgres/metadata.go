@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	"gorm.io/gorm"
+)
+
+var errInvalidJSON = errors.New("invalid JSON")
+
+// metadataNames - сущности с колонкой metadata и столбец с их названием
+var metadataNames = map[models.Entity]string{
+	models.Artists: "name",
+	models.Albums:  "title",
+	models.Tracks:  "name",
+}
+
+// Поиск записей по metadata (@> и @@ используют GIN-индекс по metadata)
+func (s *Storage) FindByMetadata(ctx context.Context, filter *models.MetadataFilter) ([]*models.MetadataMatch, error) {
+	name, ok := metadataNames[filter.Entity]
+	if !ok {
+		return nil, fmt.Errorf("%w: %w: %s", storage.ErrFindByMetadata, errUnknownEntity, filter.Entity)
+	}
+	if filter.Contains != "" && !json.Valid([]byte(filter.Contains)) {
+		return nil, fmt.Errorf("%w: %w: %s", storage.ErrFindByMetadata, errInvalidJSON, filter.Contains)
+	}
+
+	selected := "'[]'"
+	var args []any
+	if filter.Select != "" {
+		selected = "COALESCE((SELECT jsonb_agg(v) FROM jsonb_path_query(metadata, ?::jsonpath) v), '[]')::text"
+		args = append(args, filter.Select)
+	}
+
+	query := s.db.WithContext(ctx).Table(string(filter.Entity)).
+		Select("id, "+name+" AS name, metadata::text AS metadata, "+selected+" AS selected", args...)
+	if filter.Contains != "" {
+		query = query.Where("metadata @> ?::jsonb", filter.Contains)
+	}
+	if filter.Predicate != "" {
+		query = query.Where("metadata @@ ?::jsonpath", filter.Predicate)
+	}
+
+	var matches []*models.MetadataMatch
+	if err := query.Order(name + ", id").Limit(filter.Limit).Scan(&matches).Error; err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrFindByMetadata, err)
+	}
+
+	return matches, nil
+}
+
+// Установка значения по вложенному ключу path (недостающие объекты на пути создаются);
+// value = nil удаляет ключ. Изменение, после которого известные поля имеют
+// неверный тип, отклоняется (models.ErrInvalidMetadata).
+func (s *Storage) UpdateMetadata(ctx context.Context, entity models.Entity, id uuid.UUID,
+	path []string, value json.RawMessage) error {
+	if _, ok := metadataNames[entity]; !ok {
+		return fmt.Errorf("%w: %w: %s", storage.ErrUpdateMetadata, errUnknownEntity, entity)
+	}
+	if value != nil && !json.Valid(value) {
+		return fmt.Errorf("%w: %w: %s", storage.ErrUpdateMetadata, errInvalidJSON, value)
+	}
+
+	var expr any
+	if value == nil {
+		expr = gorm.Expr("metadata #- ?::text[]", textArray(path))
+	} else {
+		parent := gorm.Expr("metadata")
+		for i := 1; i < len(path); i++ {
+			parent = gorm.Expr("jsonb_set(?, ?::text[], COALESCE(metadata #> ?::text[], '{}'), true)",
+				parent, textArray(path[:i]), textArray(path[:i]))
+		}
+		expr = gorm.Expr("jsonb_set(?, ?::text[], ?::jsonb, true)", parent, textArray(path), string(value))
+	}
+
+	// измененные метаданные проверяются до фиксации транзакции
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Table(string(entity)).Where("id = ?", id).Update("metadata", expr)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return storage.ErrNotFound
+		}
+
+		var metadata models.Metadata
+		if err := tx.Table(string(entity)).Select("metadata").Where("id = ?", id).
+			Row().Scan(&metadata); err != nil {
+			return err
+		}
+
+		return models.ValidateMetadata(entity, metadata)
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", storage.ErrUpdateMetadata, err)
+	}
+
+	return nil
+}
+
+// textArray - литерал text[] (срез GORM развернул бы в список параметров)
+func textArray(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		item = strings.ReplaceAll(item, `\`, `\\`)
+		quoted[i] = `"` + strings.ReplaceAll(item, `"`, `\"`) + `"`
+	}
+
+	return "{" + strings.Join(quoted, ",") + "}"
+}
@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	"gorm.io/gorm"
+)
+
+// sampleSize - количество затронутых строк, показываемых в предпросмотре
+const sampleSize = 5
+
+// mutate запрашивает подтверждение по предпросмотру (построенному без блокировок)
+// и только затем выполняет изменение f в транзакции вместе с записью в audit_log.
+// f возвращает количество фактически измененных строк.
+func (s *Storage) mutate(ctx context.Context, preview *models.MutationPreview, confirm storage.Confirm,
+	details string, f func(tx *gorm.DB) (int64, error)) (affected int64, err error) {
+	if !confirm(preview) {
+		return 0, storage.ErrNotConfirmed
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if affected, err = f(tx); err != nil {
+			return err
+		}
+
+		return audit(tx, preview.Operation, details, affected)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
+
+// audit добавляет запись в audit_log в рамках транзакции изменения
+// (пользователь и роль берутся из session_user/current_user по умолчанию)
+func audit(tx *gorm.DB, operation, details string, rowsAffected int64) error {
+	if err := tx.Exec(`INSERT INTO audit_log (operation, details, rows_affected) VALUES (?, ?, ?)`,
+		operation, details, rowsAffected).Error; err != nil {
+		return fmt.Errorf("%w: %w", storage.ErrAudit, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hahaclassic/databases/pkg/pager"
+	"gorm.io/gorm"
+)
+
+// keysetScope добавляет к запросу условие на курсор, ORDER BY и LIMIT для страницы q.
+func keysetScope[T any](k *pager.Keyset[T], q *pager.Query) (func(*gorm.DB) *gorm.DB, error) {
+	order, err := k.Order(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if order.Cursor != nil {
+			placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(order.Cursor)), ", ")
+			db = db.Where(fmt.Sprintf("%s %s (%s)", order.Key(), order.Op(), placeholders), order.Cursor...)
+		}
+
+		return db.Order(order.By()).Limit(order.Limit)
+	}, nil
+}
+
+// contains фильтрует записи по подстроке в столбце column (пустой фильтр - без условия)
+func contains(column, filter string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter == "" {
+			return db
+		}
+
+		return db.Where(column+" ILIKE ?", "%"+pager.EscapeLike(filter)+"%")
+	}
+}
@@ -2,32 +2,54 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hahaclassic/databases/07_gorm/config"
 	"github.com/hahaclassic/databases/07_gorm/internal/models"
 	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	"github.com/hahaclassic/databases/pkg/pager"
+	"github.com/jackc/pgx/v5/pgtype"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 type Storage struct {
-	db *gorm.DB
+	db  *gorm.DB
+	log *sqlLogger
 }
 
 func New(ctx context.Context, config *config.PostgresConfig) (*Storage, error) {
-	dbURL := fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=%s",
-		config.User, config.Password, net.JoinHostPort(config.Host, config.Port), config.DB, config.SSLMode)
-
-	db, err := gorm.Open(postgres.Open(dbURL), &gorm.Config{})
+	sqlLogger, startExplain, err := newSQLLogger(&config.Log)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", storage.ErrStorageConnection, err)
 	}
 
-	return &Storage{db}, nil
+	db, err := gorm.Open(postgres.Open(config.URL()), &gorm.Config{Logger: sqlLogger})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrStorageConnection, errors.Join(err, sqlLogger.Close()))
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrStorageConnection, errors.Join(err, sqlLogger.Close()))
+	}
+	startExplain(sqlDB)
+
+	return &Storage{db: db, log: sqlLogger}, nil
+}
+
+// Close останавливает сбор планов запросов, закрывает соединения и файлы логов
+func (s *Storage) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return errors.Join(err, s.log.Close())
+	}
+
+	// логгер останавливается первым: EXPLAIN выполняется через те же соединения
+	return errors.Join(s.log.Close(), sqlDB.Close())
 }
 
 // Самые прослушиваемые limit треков
@@ -88,28 +110,75 @@ func (s *Storage) ArtistsWithReleasedAlbumYear(ctx context.Context, year int) ([
 }
 
 // Получение списка всех пользователей, старше указанного возраста
-func (s *Storage) UsersOlderThan(ctx context.Context, age int) ([]*models.User, error) {
-	var users []*models.User
+// (постранично, с фильтром по имени)
+func (s *Storage) UsersOlderThan(ctx context.Context, age int, q *pager.Query) (*pager.Page[*models.User], error) {
+	paginate, err := keysetScope(usersKeyset, q)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrUsersOlderThan, err)
+	}
 
+	var users []*models.User
 	if err := s.db.WithContext(ctx).
 		Model(&models.User{}).
 		Where("DATE_PART('year', AGE(birth_date)) > ?", age).
+		Scopes(contains("name", q.Filter), paginate).
 		Find(&users).Error; err != nil {
 		return nil, fmt.Errorf("%w: %w", storage.ErrUsersOlderThan, err)
 	}
 
-	return users, nil
+	return usersKeyset.Page(q, users)
+}
+
+var usersKeyset = &pager.Keyset[*models.User]{
+	Columns: map[string]pager.Column[*models.User]{
+		models.SortByName:              {Expr: "name", Value: func(u *models.User) any { return u.Name }},
+		models.SortByBirthDate:         {Expr: "birth_date", Value: func(u *models.User) any { return time.Time(u.BirthDate) }},
+		models.SortByRegistrationDate:  {Expr: "registration_date", Value: func(u *models.User) any { return u.RegistrationDate }},
+		models.SortByPremiumExpiration: {Expr: "COALESCE(premium_expiration, '-infinity')", Value: premiumExpirationKey},
+	},
+	TieBreakers: []pager.Column[*models.User]{
+		{Expr: "id", Value: func(u *models.User) any { return u.ID }},
+	},
+}
+
+// premiumExpirationKey - значение ключа premium_expiration для курсора: NULL (нулевое
+// время в модели) сортируется как -infinity, иначе сравнение с курсором дает NULL
+func premiumExpirationKey(u *models.User) any {
+	if u.PremiumExpiration.IsZero() {
+		return pgtype.Timestamptz{InfinityModifier: pgtype.NegativeInfinity, Valid: true}
+	}
+
+	return u.PremiumExpiration
 }
 
 // Однотабличный запрос
-// Получение всех треков одного жанра
-func (s *Storage) TracksByGenre(ctx context.Context, genre string) ([]*models.Track, error) {
+// Получение всех треков одного жанра (постранично, с фильтром по названию)
+func (s *Storage) TracksByGenre(ctx context.Context, genre string, q *pager.Query) (*pager.Page[*models.Track], error) {
+	paginate, err := keysetScope(tracksKeyset, q)
+	if err != nil {
+		return nil, err
+	}
+
 	var tracks []*models.Track
-	if err := s.db.WithContext(ctx).Where("genre = ?", genre).Find(&tracks).Error; err != nil {
+	if err := s.db.WithContext(ctx).
+		Where("genre = ?", genre).
+		Scopes(contains("name", q.Filter), paginate).
+		Find(&tracks).Error; err != nil {
 		return nil, err
 	}
 
-	return tracks, nil
+	return tracksKeyset.Page(q, tracks)
+}
+
+var tracksKeyset = &pager.Keyset[*models.Track]{
+	Columns: map[string]pager.Column[*models.Track]{
+		models.SortByStreamCount: {Expr: "stream_count", Value: func(t *models.Track) any { return t.StreamCount }},
+		models.SortByName:        {Expr: "name", Value: func(t *models.Track) any { return t.Name }},
+		models.SortByDuration:    {Expr: "duration", Value: func(t *models.Track) any { return t.Duration }},
+	},
+	TieBreakers: []pager.Column[*models.Track]{
+		{Expr: "id", Value: func(t *models.Track) any { return t.ID }},
+	},
 }
 
 // Многотабличный запрос
@@ -131,17 +200,6 @@ func (s *Storage) AddUser(ctx context.Context, user *models.User) error {
 	return s.db.WithContext(ctx).Create(&user).Error
 }
 
-// Обновление username
-func (s *Storage) UpdateUserName(ctx context.Context, userID uuid.UUID, newName string) error {
-	return s.db.WithContext(ctx).Model(&models.User{}).
-		Where("id = ?", userID).Update("name", newName).Error
-}
-
-// Удаление пользователя
-func (s *Storage) DeleteUser(ctx context.Context, userID uuid.UUID) error {
-	return s.db.WithContext(ctx).Delete(&models.User{}, userID).Error
-}
-
 // Получение альбомов исполнителя
 func (s *Storage) AlbumsByArtist(ctx context.Context, artistID uuid.UUID) ([]*models.Album, error) {
 	var albums []*models.Album
@@ -154,40 +212,3 @@ func (s *Storage) AlbumsByArtist(ctx context.Context, artistID uuid.UUID) ([]*mo
 
 	return albums, nil
 }
-
-func (s *Storage) ExportUsersToJSON(ctx context.Context) ([]byte, error) {
-	var result string
-	query := `SELECT json_agg(row_to_json(users)) FROM users`
-
-	if err := s.db.WithContext(ctx).Raw(query).Scan(&result).Error; err != nil {
-		return nil, fmt.Errorf("failed to export users to JSON: %w", err)
-	}
-
-	return []byte(result), nil
-}
-
-func (s *Storage) ImportUsers(ctx context.Context, users []*models.User) error {
-	defaultUsers := make([]struct {
-		ID                uuid.UUID `json:"id"`
-		Name              string    `json:"name"`
-		RegistrationDate  time.Time `json:"registration_date"`
-		BirthDate         time.Time `json:"birth_date"`
-		Premium           bool      `json:"premium"`
-		PremiumExpiration time.Time `json:"premium_expiration"`
-	}, len(users))
-
-	for i := range users {
-		defaultUsers[i].ID = users[i].ID
-		defaultUsers[i].Name = users[i].Name
-		defaultUsers[i].RegistrationDate = users[i].RegistrationDate
-		defaultUsers[i].BirthDate = time.Time(users[i].BirthDate)
-		defaultUsers[i].Premium = users[i].Premium
-		defaultUsers[i].PremiumExpiration = users[i].PremiumExpiration
-	}
-
-	if err := s.db.WithContext(ctx).Table("temp_users").Create(&defaultUsers).Error; err != nil {
-		return fmt.Errorf("failed to insert users into the database: %w", err)
-	}
-
-	return nil
-}
@@ -0,0 +1,222 @@
+package postgres
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+)
+
+var errInvalidDocument = errors.New("invalid document")
+
+// recordWriter - потоковая запись выгрузки в одном из форматов
+type recordWriter interface {
+	write(entity models.Entity, record any) error
+	close() error
+}
+
+// newRecordWriter создает писателя формата format; tagged - выгрузка нескольких
+// сущностей (записи помечаются своей сущностью)
+func newRecordWriter(w io.Writer, format models.Format, tagged bool) (recordWriter, error) {
+	switch {
+	case format == models.FormatJSON && !tagged:
+		return &arrayWriter{w: w, enc: json.NewEncoder(w)}, nil
+	case format == models.FormatJSON:
+		return &documentWriter{w: w, enc: json.NewEncoder(w)}, nil
+	case format == models.FormatNDJSON && !tagged:
+		return &lineWriter{enc: json.NewEncoder(w)}, nil
+	case format == models.FormatNDJSON:
+		return &lineWriter{enc: json.NewEncoder(w), tagged: true}, nil
+	default:
+		return nil, fmt.Errorf("%w: %d", errUnknownFormat, format)
+	}
+}
+
+// arrayWriter - JSON-массив записей (пустая выгрузка - "[]")
+type arrayWriter struct {
+	w     io.Writer
+	enc   *json.Encoder
+	count int
+}
+
+func (a *arrayWriter) write(_ models.Entity, record any) error {
+	sep := ","
+	if a.count == 0 {
+		sep = "["
+	}
+	if _, err := io.WriteString(a.w, sep); err != nil {
+		return err
+	}
+	a.count++
+
+	return a.enc.Encode(record)
+}
+
+func (a *arrayWriter) close() error {
+	end := "]\n"
+	if a.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(a.w, end)
+
+	return err
+}
+
+// documentWriter - JSON-объект {сущность: [записи], ...}; записи одной
+// сущности должны идти подряд
+type documentWriter struct {
+	w      io.Writer
+	enc    *json.Encoder
+	entity models.Entity
+}
+
+func (d *documentWriter) write(entity models.Entity, record any) error {
+	sep := ","
+	if entity != d.entity {
+		key, err := json.Marshal(entity)
+		if err != nil {
+			return err
+		}
+
+		sep = "],\n" + string(key) + ":["
+		if d.entity == "" {
+			sep = "{" + string(key) + ":["
+		}
+		d.entity = entity
+	}
+	if _, err := io.WriteString(d.w, sep); err != nil {
+		return err
+	}
+
+	return d.enc.Encode(record)
+}
+
+func (d *documentWriter) close() error {
+	end := "]}\n"
+	if d.entity == "" {
+		end = "{}\n"
+	}
+	_, err := io.WriteString(d.w, end)
+
+	return err
+}
+
+// lineWriter - NDJSON: запись на строку (tagged - {"entity": ..., "record": ...})
+type lineWriter struct {
+	enc    *json.Encoder
+	tagged bool
+}
+
+type envelope struct {
+	Entity models.Entity   `json:"entity"`
+	Record json.RawMessage `json:"record"`
+}
+
+func (l *lineWriter) write(entity models.Entity, record any) error {
+	if !l.tagged {
+		return l.enc.Encode(record)
+	}
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return l.enc.Encode(envelope{Entity: entity, Record: raw})
+}
+
+func (*lineWriter) close() error {
+	return nil
+}
+
+// readRecords читает записи одной сущности: JSON-массив или NDJSON
+func readRecords(r io.Reader) ([]json.RawMessage, error) {
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(br)
+	if first == '[' {
+		var records []json.RawMessage
+		if err := dec.Decode(&records); err != nil {
+			return nil, err
+		}
+		return records, nil
+	}
+
+	var records []json.RawMessage
+	for {
+		var record json.RawMessage
+		if err := dec.Decode(&record); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("record %d: %w", len(records), err)
+		}
+		records = append(records, record)
+	}
+}
+
+// readSubgraph читает выгрузку нескольких сущностей: JSON-объект
+// {сущность: [записи]} или NDJSON с записями {"entity": ..., "record": ...}
+func readSubgraph(r io.Reader) (map[models.Entity][]json.RawMessage, error) {
+	records := make(map[models.Entity][]json.RawMessage)
+
+	dec := json.NewDecoder(r)
+	for line := 0; ; line++ {
+		var value map[string]json.RawMessage
+		if err := dec.Decode(&value); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("value %d: %w", line, err)
+		}
+
+		entity, hasEntity := value["entity"]
+		record, hasRecord := value["record"]
+		if len(value) == 2 && hasEntity && hasRecord {
+			var e models.Entity
+			if err := json.Unmarshal(entity, &e); err != nil {
+				return nil, fmt.Errorf("value %d: %w", line, err)
+			}
+			if _, ok := entityCodecs[e]; !ok {
+				return nil, fmt.Errorf("value %d: %w: %s", line, errUnknownEntity, e)
+			}
+			records[e] = append(records[e], record)
+			continue
+		}
+
+		for key, raw := range value {
+			e := models.Entity(key)
+			if _, ok := entityCodecs[e]; !ok {
+				return nil, fmt.Errorf("%w: %w: %s", errInvalidDocument, errUnknownEntity, key)
+			}
+
+			var list []json.RawMessage
+			if err := json.Unmarshal(raw, &list); err != nil {
+				return nil, fmt.Errorf("%w: %s: %w", errInvalidDocument, key, err)
+			}
+			records[e] = append(records[e], list...)
+		}
+	}
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+
+		return b, br.UnreadByte()
+	}
+}
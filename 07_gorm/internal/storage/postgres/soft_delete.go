@@ -0,0 +1,267 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ownedPlaylists - подзапрос id плейлистов, владельцем которых является пользователь
+func ownedPlaylists(tx *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return tx.Model(&models.UserPlaylist{}).Select("playlist_id").
+		Where("user_id = ? AND access_level = ?", userID, models.Owner)
+}
+
+// Мягкое удаление пользователя вместе с плейлистами, которыми он владеет
+// (строки получают одинаковый deleted_at и восстанавливаются вместе).
+// Выполняется в транзакции после подтверждения.
+func (s *Storage) DeleteUser(ctx context.Context, userID uuid.UUID, confirm storage.Confirm) (int64, error) {
+	preview := &models.MutationPreview{
+		Operation: "DeleteUser",
+		Headers:   []string{"User ID", "Name", "Owned Playlists"},
+	}
+
+	db := s.db.WithContext(ctx)
+	var (
+		user      models.User
+		playlists int64
+	)
+	err := firstOrNotFound(db.Where("id = ?", userID), &user)
+	if err == nil {
+		err = db.Model(&models.Playlist{}).Where("id IN (?)", ownedPlaylists(db, userID)).Count(&playlists).Error
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", storage.ErrDeleteUser, err)
+	}
+	preview.RowsAffected = 1 + playlists
+	preview.Sample = [][]any{{user.ID, user.Name, playlists}}
+
+	affected, err := s.mutate(ctx, preview, confirm, fmt.Sprintf("user %s", userID), func(tx *gorm.DB) (int64, error) {
+		var user models.User
+		if err := firstOrNotFound(tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID), &user); err != nil {
+			return 0, err
+		}
+
+		deletedAt := time.Now()
+		playlists := tx.Model(&models.Playlist{}).Where("id IN (?)", ownedPlaylists(tx, userID)).
+			Update("deleted_at", deletedAt)
+		if playlists.Error != nil {
+			return 0, playlists.Error
+		}
+
+		res := tx.Model(&user).Update("deleted_at", deletedAt)
+
+		return res.RowsAffected + playlists.RowsAffected, res.Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", storage.ErrDeleteUser, err)
+	}
+
+	return affected, nil
+}
+
+// Мягкое удаление плейлиста
+func (s *Storage) DeletePlaylist(ctx context.Context, playlistID uuid.UUID, confirm storage.Confirm) (int64, error) {
+	preview := &models.MutationPreview{
+		Operation:    "DeletePlaylist",
+		RowsAffected: 1,
+		Headers:      []string{"Playlist ID", "Title", "Tracks"},
+	}
+
+	db := s.db.WithContext(ctx)
+	var (
+		playlist models.Playlist
+		tracks   int64
+	)
+	err := firstOrNotFound(db.Where("id = ?", playlistID), &playlist)
+	if err == nil {
+		err = db.Model(&models.PlaylistTrack{}).Where("playlist_id = ?", playlistID).Count(&tracks).Error
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", storage.ErrDeletePlaylist, err)
+	}
+	preview.Sample = [][]any{{playlist.ID, playlist.Title, tracks}}
+
+	affected, err := s.mutate(ctx, preview, confirm, fmt.Sprintf("playlist %s", playlistID), func(tx *gorm.DB) (int64, error) {
+		var playlist models.Playlist
+		if err := firstOrNotFound(tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", playlistID), &playlist); err != nil {
+			return 0, err
+		}
+
+		res := tx.Delete(&playlist)
+
+		return res.RowsAffected, res.Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", storage.ErrDeletePlaylist, err)
+	}
+
+	return affected, nil
+}
+
+// Восстановление пользователя и плейлистов, удаленных вместе с ним
+func (s *Storage) RestoreUser(ctx context.Context, userID uuid.UUID, confirm storage.Confirm) (int64, error) {
+	preview := &models.MutationPreview{
+		Operation: "RestoreUser",
+		Headers:   []string{"User ID", "Name", "Restored Playlists"},
+	}
+
+	db := s.db.WithContext(ctx)
+	var (
+		user      models.User
+		playlists int64
+	)
+	err := firstOrNotFound(db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", userID), &user)
+	if err == nil {
+		err = db.Unscoped().Model(&models.Playlist{}).
+			Where("id IN (?) AND deleted_at = ?", ownedPlaylists(db, userID), user.DeletedAt).Count(&playlists).Error
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", storage.ErrRestore, err)
+	}
+	preview.RowsAffected = 1 + playlists
+	preview.Sample = [][]any{{user.ID, user.Name, playlists}}
+
+	affected, err := s.mutate(ctx, preview, confirm, fmt.Sprintf("user %s", userID), func(tx *gorm.DB) (int64, error) {
+		var user models.User
+		if err := firstOrNotFound(tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NOT NULL", userID), &user); err != nil {
+			return 0, err
+		}
+
+		playlists := tx.Unscoped().Model(&models.Playlist{}).
+			Where("id IN (?) AND deleted_at = ?", ownedPlaylists(tx, userID), user.DeletedAt).
+			Update("deleted_at", nil)
+		if playlists.Error != nil {
+			return 0, playlists.Error
+		}
+
+		res := tx.Unscoped().Model(&user).Update("deleted_at", nil)
+
+		return res.RowsAffected + playlists.RowsAffected, res.Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", storage.ErrRestore, err)
+	}
+
+	return affected, nil
+}
+
+// Восстановление плейлиста
+func (s *Storage) RestorePlaylist(ctx context.Context, playlistID uuid.UUID, confirm storage.Confirm) (int64, error) {
+	preview := &models.MutationPreview{
+		Operation:    "RestorePlaylist",
+		RowsAffected: 1,
+		Headers:      []string{"Playlist ID", "Title"},
+	}
+
+	var playlist models.Playlist
+	err := firstOrNotFound(s.db.WithContext(ctx).Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", playlistID), &playlist)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", storage.ErrRestore, err)
+	}
+	preview.Sample = [][]any{{playlist.ID, playlist.Title}}
+
+	affected, err := s.mutate(ctx, preview, confirm, fmt.Sprintf("playlist %s", playlistID), func(tx *gorm.DB) (int64, error) {
+		var playlist models.Playlist
+		if err := firstOrNotFound(tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NOT NULL", playlistID), &playlist); err != nil {
+			return 0, err
+		}
+
+		res := tx.Unscoped().Model(&playlist).Update("deleted_at", nil)
+
+		return res.RowsAffected, res.Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", storage.ErrRestore, err)
+	}
+
+	return affected, nil
+}
+
+// firstOrNotFound - первая запись запроса или storage.ErrNotFound
+func firstOrNotFound(query *gorm.DB, dest any) error {
+	err := query.First(dest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return storage.ErrNotFound
+	}
+
+	return err
+}
+
+// Мягко удаленные пользователи и плейлисты (последние удаленные первыми)
+func (s *Storage) Trash(ctx context.Context, limit int) ([]*models.DeletedRecord, error) {
+	var records []*models.DeletedRecord
+
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT * FROM (
+			SELECT ? AS entity, id, name, deleted_at FROM users WHERE deleted_at IS NOT NULL
+			UNION ALL
+			SELECT ? AS entity, id, title AS name, deleted_at FROM playlists WHERE deleted_at IS NOT NULL
+		) deleted
+		ORDER BY deleted_at DESC, entity, id
+		LIMIT ?`, models.Users, models.Playlists, limit).
+		Scan(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrTrash, err)
+	}
+
+	return records, nil
+}
+
+// Окончательное удаление пользователей и плейлистов, удаленных мягко раньше before
+// (связи удаляются каскадно)
+func (s *Storage) PurgeDeleted(ctx context.Context, before time.Time, confirm storage.Confirm) (int64, error) {
+	preview := &models.MutationPreview{
+		Operation: "PurgeDeleted",
+		Headers:   []string{"Entity", "ID", "Deleted At"},
+	}
+
+	var sample []*struct {
+		models.DeletedRecord
+		Total int64
+	}
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT entity, id, deleted_at, COUNT(*) OVER () AS total FROM (
+			SELECT ? AS entity, id, deleted_at FROM users WHERE deleted_at < ?
+			UNION ALL
+			SELECT ? AS entity, id, deleted_at FROM playlists WHERE deleted_at < ?
+		) purged
+		ORDER BY deleted_at, entity, id
+		LIMIT ?`, models.Users, before, models.Playlists, before, sampleSize).
+		Scan(&sample).Error
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", storage.ErrPurge, err)
+	}
+	for _, record := range sample {
+		preview.RowsAffected = record.Total
+		preview.Sample = append(preview.Sample, []any{record.Entity, record.ID,
+			record.DeletedAt.Format(time.DateTime)})
+	}
+
+	details := fmt.Sprintf("deleted before %s", before.Format(time.RFC3339))
+	affected, err := s.mutate(ctx, preview, confirm, details, func(tx *gorm.DB) (int64, error) {
+		users := tx.Unscoped().Where("deleted_at < ?", before).Delete(&models.User{})
+		if users.Error != nil {
+			return 0, users.Error
+		}
+
+		playlists := tx.Unscoped().Where("deleted_at < ?", before).Delete(&models.Playlist{})
+
+		return users.RowsAffected + playlists.RowsAffected, playlists.Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", storage.ErrPurge, err)
+	}
+
+	return affected, nil
+}
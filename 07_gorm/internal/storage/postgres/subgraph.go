@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	"gorm.io/gorm"
+)
+
+// artistSubgraph - исполнитель, его альбомы (в том числе альбомы треков, где он
+// приглашенный исполнитель) со всеми треками, связи и все исполнители этих связей
+func (s *Storage) artistSubgraph(ctx context.Context, artistID uuid.UUID) (*models.Subgraph, error) {
+	db := s.db.WithContext(ctx)
+	if err := exists[models.Artist](db, artistID); err != nil {
+		return nil, err
+	}
+
+	graph := &models.Subgraph{}
+	albumIDs := db.Model(&models.AlbumArtist{}).Select("album_id").Where("artist_id = ?", artistID)
+	featuredAlbumIDs := db.Model(&models.Track{}).Select("album_id").
+		Where("id IN (?)", db.Model(&models.TrackArtist{}).Select("track_id").Where("artist_id = ?", artistID))
+
+	err := db.Where("id IN (?) OR id IN (?)", albumIDs, featuredAlbumIDs).Order("id").Find(&graph.Albums).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(graph.Albums))
+	for _, album := range graph.Albums {
+		ids = append(ids, album.ID)
+	}
+	if err := db.Where("album_id IN ?", ids).Order("album_id, order_in_album").Find(&graph.Tracks).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("album_id IN ?", ids).Order("album_id, artist_id").Find(&graph.AlbumArtists).Error; err != nil {
+		return nil, err
+	}
+
+	// исполнитель попадает в подграф, даже если у него нет альбомов и треков
+	return graph, s.completeTracks(db, graph, artistID)
+}
+
+// userSubgraph - пользователь, его плейлисты с треками, альбомы этих треков,
+// связи треков и альбомов с исполнителями и сами исполнители
+func (s *Storage) userSubgraph(ctx context.Context, userID uuid.UUID) (*models.Subgraph, error) {
+	db := s.db.WithContext(ctx)
+	if err := exists[models.User](db, userID); err != nil {
+		return nil, err
+	}
+
+	graph := &models.Subgraph{}
+	if err := db.Where("id = ?", userID).Find(&graph.Users).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userID).Order("playlist_id").Find(&graph.UserPlaylists).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(graph.UserPlaylists))
+	for _, link := range graph.UserPlaylists {
+		ids = append(ids, link.PlaylistID)
+	}
+	// мягко удаленные плейлисты тоже выгружаются: на них ссылаются связи пользователя
+	if err := db.Unscoped().Where("id IN ?", ids).Order("id").Find(&graph.Playlists).Error; err != nil {
+		return nil, err
+	}
+	err := db.Where("playlist_id IN ?", ids).Order("playlist_id, track_order").Find(&graph.PlaylistTracks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	trackIDs := db.Model(&models.PlaylistTrack{}).Select("track_id").Where("playlist_id IN ?", ids)
+	if err := db.Where("id IN (?)", trackIDs).Order("album_id, order_in_album").Find(&graph.Tracks).Error; err != nil {
+		return nil, err
+	}
+
+	albumIDs := make([]uuid.UUID, 0, len(graph.Tracks))
+	for _, track := range graph.Tracks {
+		albumIDs = append(albumIDs, track.AlbumID)
+	}
+	if err := db.Where("id IN ?", albumIDs).Order("id").Find(&graph.Albums).Error; err != nil {
+		return nil, err
+	}
+	err = db.Where("album_id IN ?", albumIDs).Order("album_id, artist_id").Find(&graph.AlbumArtists).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return graph, s.completeTracks(db, graph)
+}
+
+// completeTracks добавляет в подграф связи его треков с исполнителями и всех
+// исполнителей, на которых ссылаются связи, а также исполнителей artistIDs
+func (s *Storage) completeTracks(db *gorm.DB, graph *models.Subgraph, artistIDs ...uuid.UUID) error {
+	ids := make([]uuid.UUID, 0, len(graph.Tracks))
+	for _, track := range graph.Tracks {
+		ids = append(ids, track.ID)
+	}
+	if err := db.Where("track_id IN ?", ids).Order("track_id, artist_id").Find(&graph.TrackArtists).Error; err != nil {
+		return err
+	}
+
+	for _, link := range graph.AlbumArtists {
+		artistIDs = append(artistIDs, link.ArtistID)
+	}
+	for _, link := range graph.TrackArtists {
+		artistIDs = append(artistIDs, link.ArtistID)
+	}
+
+	return db.Where("id IN ?", artistIDs).Order("id").Find(&graph.Artists).Error
+}
+
+func exists[T any](db *gorm.DB, id uuid.UUID) error {
+	var record T
+	err := db.Select("id").Where("id = ?", id).Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return storage.ErrNotFound
+	}
+
+	return err
+}
+
+// subgraphRecords - записи сущности в подграфе
+func subgraphRecords(graph *models.Subgraph, entity models.Entity) []any {
+	switch entity {
+	case models.Artists:
+		return toAny(graph.Artists)
+	case models.Albums:
+		return toAny(graph.Albums)
+	case models.Tracks:
+		return toAny(graph.Tracks)
+	case models.AlbumArtists:
+		return toAny(graph.AlbumArtists)
+	case models.TrackArtists:
+		return toAny(graph.TrackArtists)
+	case models.Users:
+		return toAny(graph.Users)
+	case models.Playlists:
+		return toAny(graph.Playlists)
+	case models.UserPlaylists:
+		return toAny(graph.UserPlaylists)
+	case models.PlaylistTracks:
+		return toAny(graph.PlaylistTracks)
+	default:
+		return nil
+	}
+}
+
+func toAny[T any](records []T) []any {
+	result := make([]any, len(records))
+	for i, record := range records {
+		result[i] = record
+	}
+
+	return result
+}
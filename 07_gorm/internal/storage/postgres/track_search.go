@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	"github.com/hahaclassic/databases/pkg/pager"
+	"gorm.io/gorm"
+)
+
+// trackSortColumns - столбцы сортировки поиска ("Album" - псевдоним Joins("Album"))
+var trackSortColumns = map[string]string{
+	models.SortByStreamCount: "tracks.stream_count",
+	models.SortByName:        "tracks.name",
+	models.SortByDuration:    "tracks.duration",
+	models.SortByReleaseDate: `"Album".release_date`,
+}
+
+// Поиск треков по фильтру (с альбомом и исполнителями). Возвращает страницу
+// треков и общее количество найденных.
+func (s *Storage) SearchTracks(ctx context.Context, filter *models.TrackFilter) ([]*models.Track, int64, error) {
+	sortColumn := models.SortByStreamCount
+	if filter.SortBy != "" {
+		sortColumn = filter.SortBy
+	}
+	order, ok := trackSortColumns[sortColumn]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: %w: %q", storage.ErrSearchTracks, pager.ErrInvalidSortColumn, filter.SortBy)
+	}
+	if filter.Desc {
+		order += " DESC"
+	}
+
+	filtered := func() *gorm.DB {
+		return s.db.WithContext(ctx).Model(&models.Track{}).Joins("Album").Scopes(
+			inGenres(filter.Genres),
+			isExplicit(filter.Explicit),
+			between("tracks.duration", filter.MinDuration, filter.MaxDuration),
+			between("tracks.stream_count", filter.MinStreams, filter.MaxStreams),
+			between(`EXTRACT(YEAR FROM "Album".release_date)`, filter.MinReleaseYear, filter.MaxReleaseYear),
+			contains(`"Album".title`, filter.Album),
+			byArtist(filter.Artist),
+		)
+	}
+
+	var total int64
+	if err := filtered().Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", storage.ErrSearchTracks, err)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = pager.DefaultSize
+	}
+
+	var tracks []*models.Track
+	if err := filtered().Preload("Artists").
+		Order(order + ", tracks.id").Limit(limit).Offset(filter.Offset).
+		Find(&tracks).Error; err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", storage.ErrSearchTracks, err)
+	}
+
+	return tracks, total, nil
+}
+
+func inGenres(genres []string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(genres) == 0 {
+			return db
+		}
+
+		return db.Where("tracks.genre IN ?", genres)
+	}
+}
+
+func isExplicit(explicit *bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if explicit == nil {
+			return db
+		}
+
+		return db.Where("tracks.explicit = ?", *explicit)
+	}
+}
+
+// between ограничивает expr диапазоном [min, max] (0 - граница не задана)
+func between[T int | int64](expr string, min, max T) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if min != 0 {
+			db = db.Where(expr+" >= ?", min)
+		}
+		if max != 0 {
+			db = db.Where(expr+" <= ?", max)
+		}
+
+		return db
+	}
+}
+
+// byArtist оставляет треки, у которых есть исполнитель с подстрокой name в имени
+func byArtist(name string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if name == "" {
+			return db
+		}
+
+		return db.Where(`EXISTS (SELECT 1 FROM tracks_by_artists ta
+			JOIN artists ar ON ar.id = ta.artist_id
+			WHERE ta.track_id = tracks.id AND ar.name ILIKE ?)`, "%"+pager.EscapeLike(name)+"%")
+	}
+}
@@ -0,0 +1,335 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errUnknownEntity = errors.New("unknown entity")
+	errUnknownFormat = errors.New("unknown format")
+)
+
+type validator interface {
+	Validate() error
+}
+
+// entityCodec - выгрузка и идемпотентная загрузка (upsert) записей одной сущности
+type entityCodec struct {
+	export func(ctx context.Context, db *gorm.DB, w recordWriter) (int, error)
+	// check - ключи записей, прошедших разбор и проверку (для предпросмотра загрузки)
+	check  func(records []json.RawMessage) []string
+	upsert func(tx *gorm.DB, records []json.RawMessage, report *models.ImportReport) ([]string, error)
+}
+
+// newEntityCodec создает кодек сущности по GORM-модели T; key - ключ записи для отчета
+func newEntityCodec[T any](entity models.Entity, key func(*T) string) *entityCodec {
+	return &entityCodec{
+		export: func(ctx context.Context, db *gorm.DB, w recordWriter) (count int, err error) {
+			var zero T
+			stmt := &gorm.Statement{DB: db}
+			if err := stmt.Parse(&zero); err != nil {
+				return 0, err
+			}
+
+			order := strings.Join(stmt.Schema.PrimaryFieldDBNames, ", ")
+			// Unscoped - в выгрузку попадают и мягко удаленные строки (с deleted_at)
+			rows, err := db.WithContext(ctx).Unscoped().Model(&zero).Order(order).Rows()
+			if err != nil {
+				return 0, err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var record T
+				if err := db.ScanRows(rows, &record); err != nil {
+					return count, err
+				}
+				if err := w.write(entity, &record); err != nil {
+					return count, err
+				}
+				count++
+			}
+
+			return count, rows.Err()
+		},
+
+		check: func(raws []json.RawMessage) []string {
+			records, _ := decodeRecords(entity, raws, key, &models.ImportReport{})
+
+			keys := make([]string, 0, len(records))
+			for _, record := range records {
+				keys = append(keys, key(record))
+			}
+
+			return keys
+		},
+
+		upsert: func(tx *gorm.DB, raws []json.RawMessage, report *models.ImportReport) ([]string, error) {
+			records, indexes := decodeRecords(entity, raws, key, report)
+
+			var keys []string
+			for start := 0; start < len(records); start += importBatchSize {
+				end := min(start+importBatchSize, len(records))
+
+				imported, err := upsertBatch(tx, entity, records[start:end], indexes[start:end], key, report)
+				if err != nil {
+					return nil, err
+				}
+				for _, record := range imported {
+					keys = append(keys, key(record))
+				}
+			}
+
+			return keys, nil
+		},
+	}
+}
+
+var entityCodecs = map[models.Entity]*entityCodec{
+	models.Artists: newEntityCodec(models.Artists, func(a *models.Artist) string { return a.ID.String() }),
+	models.Albums:  newEntityCodec(models.Albums, func(a *models.Album) string { return a.ID.String() }),
+	models.Tracks:  newEntityCodec(models.Tracks, func(t *models.Track) string { return t.ID.String() }),
+	models.AlbumArtists: newEntityCodec(models.AlbumArtists, func(l *models.AlbumArtist) string {
+		return l.AlbumID.String() + "/" + l.ArtistID.String()
+	}),
+	models.TrackArtists: newEntityCodec(models.TrackArtists, func(l *models.TrackArtist) string {
+		return l.TrackID.String() + "/" + l.ArtistID.String()
+	}),
+	models.Users:     newEntityCodec(models.Users, func(u *models.User) string { return u.ID.String() }),
+	models.Playlists: newEntityCodec(models.Playlists, func(p *models.Playlist) string { return p.ID.String() }),
+	models.UserPlaylists: newEntityCodec(models.UserPlaylists, func(l *models.UserPlaylist) string {
+		return l.UserID.String() + "/" + l.PlaylistID.String()
+	}),
+	models.PlaylistTracks: newEntityCodec(models.PlaylistTracks, func(l *models.PlaylistTrack) string {
+		return l.PlaylistID.String() + "/" + l.TrackID.String()
+	}),
+}
+
+// decodeRecords разбирает и проверяет записи; ошибочные попадают в report.
+// indexes - номера записей в исходном списке.
+func decodeRecords[T any](entity models.Entity, raws []json.RawMessage, key func(*T) string,
+	report *models.ImportReport) (records []*T, indexes []int) {
+	records = make([]*T, 0, len(raws))
+	indexes = make([]int, 0, len(raws))
+	for i, raw := range raws {
+		record := new(T)
+		err := json.Unmarshal(raw, record)
+		if v, ok := any(record).(validator); ok && err == nil {
+			err = v.Validate()
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, &models.RecordError{
+				Entity: entity, Index: i, Key: key(record), Err: err})
+			continue
+		}
+		records = append(records, record)
+		indexes = append(indexes, i)
+	}
+
+	return records, indexes
+}
+
+// upsertBatch добавляет или обновляет пачку записей. Если пачка отклонена базой,
+// записи добавляются по одной, чтобы найти и пропустить ошибочные.
+func upsertBatch[T any](tx *gorm.DB, entity models.Entity, batch []*T, indexes []int,
+	key func(*T) string, report *models.ImportReport) ([]*T, error) {
+	const batchSavePoint, recordSavePoint = "import_batch", "import_record"
+
+	if err := tx.SavePoint(batchSavePoint).Error; err != nil {
+		return nil, err
+	}
+	if err := upsert(tx, entity, batch); err == nil {
+		return batch, nil
+	}
+	if err := tx.RollbackTo(batchSavePoint).Error; err != nil {
+		return nil, err
+	}
+
+	imported := make([]*T, 0, len(batch))
+	for i, record := range batch {
+		if err := tx.SavePoint(recordSavePoint).Error; err != nil {
+			return nil, err
+		}
+
+		if err := upsert(tx, entity, []*T{record}); err != nil {
+			if err := tx.RollbackTo(recordSavePoint).Error; err != nil {
+				return nil, err
+			}
+			report.Errors = append(report.Errors, &models.RecordError{
+				Entity: entity, Index: indexes[i], Key: key(record), Err: err})
+			continue
+		}
+		imported = append(imported, record)
+	}
+
+	return imported, nil
+}
+
+// upsert - INSERT ... ON CONFLICT (первичный ключ) DO UPDATE всех остальных столбцов
+// (DO NOTHING для таблиц связей, состоящих только из ключа). Записи с версией
+// обновляются через upsertVersioned.
+func upsert[T any](tx *gorm.DB, entity models.Entity, records []*T) error {
+	if versionedEntities[entity] {
+		return upsertVersioned(tx, entity, records)
+	}
+
+	return tx.Clauses(clause.OnConflict{UpdateAll: true}).
+		Omit(clause.Associations).Create(&records).Error
+}
+
+// Выгрузка всех записей сущности
+func (s *Storage) ExportEntity(ctx context.Context, entity models.Entity, format models.Format,
+	w io.Writer) (count int, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%w: %w", storage.ErrExport, err)
+		}
+	}()
+
+	codec, ok := entityCodecs[entity]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", errUnknownEntity, entity)
+	}
+
+	rw, err := newRecordWriter(w, format, false)
+	if err != nil {
+		return 0, err
+	}
+
+	if count, err = codec.export(ctx, s.db, rw); err != nil {
+		return count, err
+	}
+
+	return count, rw.close()
+}
+
+// Загрузка записей сущности (JSON-массив или NDJSON). Выполняется в транзакции
+// после подтверждения; повторная загрузка не создает дублей.
+func (s *Storage) ImportEntity(ctx context.Context, entity models.Entity, r io.Reader,
+	confirm storage.Confirm) (*models.ImportReport, error) {
+	if _, ok := entityCodecs[entity]; !ok {
+		return nil, fmt.Errorf("%w: %w: %s", storage.ErrImport, errUnknownEntity, entity)
+	}
+
+	records, err := readRecords(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrImport, err)
+	}
+
+	return s.importRecords(ctx, "Import "+string(entity), map[models.Entity][]json.RawMessage{entity: records}, confirm)
+}
+
+// Выгрузка подграфа: исполнитель (root = Artists) с альбомами, треками и
+// соавторами или пользователь (root = Users) с плейлистами и их треками
+func (s *Storage) ExportSubgraph(ctx context.Context, root models.Entity, id uuid.UUID,
+	format models.Format, w io.Writer) (count int, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%w: %w", storage.ErrExport, err)
+		}
+	}()
+
+	var graph *models.Subgraph
+	switch root {
+	case models.Artists:
+		graph, err = s.artistSubgraph(ctx, id)
+	case models.Users:
+		graph, err = s.userSubgraph(ctx, id)
+	default:
+		err = fmt.Errorf("%w: %s", errUnknownEntity, root)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	rw, err := newRecordWriter(w, format, true)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entity := range models.Entities {
+		for _, record := range subgraphRecords(graph, entity) {
+			if err := rw.write(entity, record); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+
+	return count, rw.close()
+}
+
+// Загрузка подграфа, выгруженного ExportSubgraph (в любом из форматов)
+func (s *Storage) ImportSubgraph(ctx context.Context, r io.Reader, confirm storage.Confirm) (*models.ImportReport, error) {
+	records, err := readSubgraph(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrImport, err)
+	}
+
+	return s.importRecords(ctx, "ImportSubgraph", records, confirm)
+}
+
+// importRecords загружает записи сущностей в порядке зависимостей в одной транзакции.
+// Предпросмотр строится по записям, прошедшим проверку, без обращения к базе.
+func (s *Storage) importRecords(ctx context.Context, operation string,
+	records map[models.Entity][]json.RawMessage, confirm storage.Confirm) (*models.ImportReport, error) {
+	preview := &models.MutationPreview{
+		Operation: operation,
+		Headers:   []string{"Entity", "Key"},
+	}
+
+	var entities []string
+	for _, entity := range models.Entities {
+		if len(records[entity]) == 0 {
+			continue
+		}
+		entities = append(entities, string(entity))
+
+		keys := entityCodecs[entity].check(records[entity])
+		for _, key := range keys {
+			if len(preview.Sample) < sampleSize {
+				preview.Sample = append(preview.Sample, []any{entity, key})
+			}
+		}
+		preview.RowsAffected += int64(len(keys))
+	}
+
+	report := &models.ImportReport{}
+	_, err := s.mutate(ctx, preview, confirm, strings.Join(entities, ", "), func(tx *gorm.DB) (int64, error) {
+		for _, entity := range models.Entities {
+			report.Total += len(records[entity])
+
+			keys, err := entityCodecs[entity].upsert(tx, records[entity], report)
+			if err != nil {
+				return 0, err
+			}
+			report.Imported += len(keys)
+		}
+
+		return int64(report.Imported), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrImport, err)
+	}
+
+	slices.SortStableFunc(report.Errors, func(a, b *models.RecordError) int {
+		if c := slices.Index(models.Entities, a.Entity) - slices.Index(models.Entities, b.Entity); c != 0 {
+			return c
+		}
+		return a.Index - b.Index
+	})
+
+	return report, nil
+}
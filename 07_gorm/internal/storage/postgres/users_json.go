@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	"gorm.io/gorm"
+)
+
+const importBatchSize = 100
+
+// Построчная выгрузка пользователей в JSON-массив (пустая таблица - "[]")
+func (s *Storage) ExportUsersToJSON(ctx context.Context, w io.Writer) (count int, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%w: %w", storage.ErrExportUsers, err)
+		}
+	}()
+
+	rows, err := s.db.WithContext(ctx).Model(&models.User{}).Order("id").Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if _, err = io.WriteString(w, "["); err != nil {
+		return 0, err
+	}
+
+	enc := json.NewEncoder(w)
+	for rows.Next() {
+		var user models.User
+		if err = s.db.ScanRows(rows, &user); err != nil {
+			return count, err
+		}
+
+		if count > 0 {
+			if _, err = io.WriteString(w, ","); err != nil {
+				return count, err
+			}
+		}
+		if err = enc.Encode(&user); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if err = rows.Err(); err != nil {
+		return count, err
+	}
+
+	_, err = io.WriteString(w, "]\n")
+
+	return count, err
+}
+
+// Импорт пользователей: записи, нарушающие ограничения users, пропускаются,
+// остальные добавляются или обновляются (ON CONFLICT (id) DO UPDATE) пачками
+// в одной транзакции, которая выполняется после подтверждения.
+func (s *Storage) ImportUsers(ctx context.Context, users []*models.User,
+	confirm storage.Confirm) (*models.ImportReport, error) {
+	report := &models.ImportReport{Total: len(users)}
+	key := func(user *models.User) string { return user.ID.String() }
+
+	preview := &models.MutationPreview{
+		Operation: "ImportUsers",
+		Headers:   []string{"User ID", "Name", "Birth Date", "Premium"},
+	}
+
+	valid := make([]*models.User, 0, len(users))
+	indexes := make([]int, 0, len(users))
+	for i, user := range users {
+		if err := user.Validate(); err != nil {
+			report.Errors = append(report.Errors, &models.RecordError{
+				Entity: models.Users, Index: i, Key: key(user), Err: err})
+			continue
+		}
+		valid = append(valid, user)
+		indexes = append(indexes, i)
+
+		if len(preview.Sample) < sampleSize {
+			preview.Sample = append(preview.Sample, []any{user.ID, user.Name,
+				user.BirthDate.Format("2006-01-02"), user.Premium})
+		}
+	}
+	preview.RowsAffected = int64(len(valid))
+
+	_, err := s.mutate(ctx, preview, confirm, "upsert into users", func(tx *gorm.DB) (int64, error) {
+		for start := 0; start < len(valid); start += importBatchSize {
+			end := min(start+importBatchSize, len(valid))
+
+			imported, err := upsertBatch(tx, models.Users, valid[start:end], indexes[start:end], key, report)
+			if err != nil {
+				return 0, err
+			}
+			report.Imported += len(imported)
+		}
+
+		return int64(report.Imported), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrImportUsers, err)
+	}
+
+	slices.SortFunc(report.Errors, func(a, b *models.RecordError) int {
+		return a.Index - b.Index
+	})
+
+	return report, nil
+}
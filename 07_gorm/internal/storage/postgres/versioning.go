@@ -0,0 +1,248 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/07_gorm/internal/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Получение пользователя (с текущей версией)
+func (s *Storage) User(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := s.db.WithContext(ctx).Where("id = ?", userID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %w", storage.ErrGetUser, storage.ErrNotFound)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrGetUser, err)
+	}
+
+	return &user, nil
+}
+
+// Получение плейлиста (с текущей версией)
+func (s *Storage) Playlist(ctx context.Context, playlistID uuid.UUID) (*models.Playlist, error) {
+	var playlist models.Playlist
+	err := s.db.WithContext(ctx).Where("id = ?", playlistID).First(&playlist).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %w", storage.ErrGetPlaylist, storage.ErrNotFound)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrGetPlaylist, err)
+	}
+
+	return &playlist, nil
+}
+
+// Обновление username, если версия пользователя не изменилась с момента чтения
+func (s *Storage) UpdateUserName(ctx context.Context, userID uuid.UUID, version int64, newName string) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return updateVersioned(tx, &models.User{}, models.Users, userID, version,
+			map[string]any{"name": newName})
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", storage.ErrUpdateUser, err)
+	}
+
+	return nil
+}
+
+// Обновление плейлиста, если его версия не изменилась с момента чтения
+func (s *Storage) UpdatePlaylist(ctx context.Context, playlistID uuid.UUID, version int64,
+	update *models.PlaylistUpdate) error {
+	changes := map[string]any{}
+	if update.Title != nil {
+		changes["title"] = *update.Title
+	}
+	if update.Description != nil {
+		changes["description"] = *update.Description
+	}
+	if update.Private != nil {
+		changes["private"] = *update.Private
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return updateVersioned(tx, &models.Playlist{}, models.Playlists, playlistID, version, changes)
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", storage.ErrUpdatePlaylist, err)
+	}
+
+	return nil
+}
+
+// updateVersioned блокирует запись, проверяет ее версию (оптимистическая блокировка),
+// применяет изменившиеся столбцы с увеличением версии и записывает их в edit_history
+func updateVersioned(tx *gorm.DB, model any, entity models.Entity, id uuid.UUID, version int64,
+	changes map[string]any) error {
+	current := map[string]any{}
+	err := tx.Model(model).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).Take(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return storage.ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if v, _ := current["version"].(int64); v != version {
+		return fmt.Errorf("%w: expected version %d, current %d", storage.ErrVersionConflict, version, v)
+	}
+
+	fields := make([]string, 0, len(changes))
+	for field, value := range changes {
+		if historyValue(current[field]) != historyValue(value) {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	sort.Strings(fields)
+
+	updates := map[string]any{"version": gorm.Expr("version + 1")}
+	edits := make([]*models.Edit, 0, len(fields))
+	for _, field := range fields {
+		updates[field] = changes[field]
+		edits = append(edits, &models.Edit{Entity: entity, RecordID: id, Field: field,
+			OldValue: historyValue(current[field]), NewValue: historyValue(changes[field]), Version: version + 1})
+	}
+
+	res := tx.Model(model).Where("id = ? AND version = ?", id, version).Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return storage.ErrVersionConflict
+	}
+
+	return tx.Create(&edits).Error
+}
+
+// versionedEntities - сущности с оптимистической блокировкой (столбец version)
+var versionedEntities = map[models.Entity]bool{models.Users: true, models.Playlists: true}
+
+// upsertVersioned загружает записи с версией: новые добавляются, у существующих
+// обновляются только изменившиеся записи. Версия из файла не переносится -
+// она увеличивается на 1, а измененные поля записываются в edit_history,
+// как при обновлении через updateVersioned.
+func upsertVersioned[T any](tx *gorm.DB, entity models.Entity, records []*T) error {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(new(T)); err != nil {
+		return err
+	}
+	ctx, sch := tx.Statement.Context, stmt.Schema
+
+	ids := make([]uuid.UUID, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, recordID(ctx, sch, record))
+	}
+
+	var current []*T
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Find(&current).Error
+	if err != nil {
+		return err
+	}
+	currentByID := make(map[uuid.UUID]*T, len(current))
+	for _, record := range current {
+		currentByID[recordID(ctx, sch, record)] = record
+	}
+
+	var columns []string
+	for _, column := range sch.DBNames {
+		if column != "id" && column != "version" {
+			columns = append(columns, column)
+		}
+	}
+
+	changed := make([]*T, 0, len(records))
+	var edits []*models.Edit
+	for _, record := range records {
+		id := recordID(ctx, sch, record)
+		old, ok := currentByID[id]
+		if !ok {
+			changed = append(changed, record)
+			continue
+		}
+
+		version, _ := fieldValue(ctx, sch.LookUpField("version"), old).(int64)
+		n := len(edits)
+		for _, column := range columns {
+			field := sch.LookUpField(column)
+			oldValue := historyValue(fieldValue(ctx, field, old))
+			newValue := historyValue(fieldValue(ctx, field, record))
+			if oldValue != newValue {
+				edits = append(edits, &models.Edit{Entity: entity, RecordID: id, Field: column,
+					OldValue: oldValue, NewValue: newValue, Version: version + 1})
+			}
+		}
+		if len(edits) > n {
+			changed = append(changed, record)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	updates := clause.AssignmentColumns(columns)
+	updates = append(updates, clause.Assignment{Column: clause.Column{Name: "version"},
+		Value: gorm.Expr(sch.Table + ".version + 1")})
+	err = tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "id"}}, DoUpdates: updates}).
+		Omit(clause.Associations).Create(&changed).Error
+	if err != nil || len(edits) == 0 {
+		return err
+	}
+
+	return tx.Create(&edits).Error
+}
+
+func recordID[T any](ctx context.Context, sch *schema.Schema, record *T) uuid.UUID {
+	id, _ := sch.PrioritizedPrimaryField.ValueOf(ctx, reflect.ValueOf(record))
+	return id.(uuid.UUID)
+}
+
+// fieldValue - значение поля записи в том виде, в котором оно хранится в базе
+// (время - в UTC, чтобы значения из файла и из базы сравнивались одинаково)
+func fieldValue[T any](ctx context.Context, field *schema.Field, record *T) any {
+	value, _ := field.ValueOf(ctx, reflect.ValueOf(record))
+	if valuer, ok := value.(driver.Valuer); ok {
+		value, _ = valuer.Value()
+	}
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC()
+	}
+
+	return value
+}
+
+func historyValue(v any) string {
+	if v == nil {
+		return ""
+	}
+
+	return fmt.Sprint(v)
+}
+
+// История изменений записей сущности (recordID = uuid.Nil - всех записей), новые первыми
+func (s *Storage) EditHistory(ctx context.Context, entity models.Entity, recordID uuid.UUID,
+	limit int) ([]*models.Edit, error) {
+	query := s.db.WithContext(ctx).Where("entity = ?", entity)
+	if recordID != uuid.Nil {
+		query = query.Where("record_id = ?", recordID)
+	}
+
+	var edits []*models.Edit
+	if err := query.Order("edited_at DESC, id DESC").Limit(limit).Find(&edits).Error; err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrEditHistory, err)
+	}
+
+	return edits, nil
+}
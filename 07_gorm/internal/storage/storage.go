@@ -2,41 +2,98 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hahaclassic/databases/07_gorm/internal/models"
+	"github.com/hahaclassic/databases/pkg/pager"
 )
 
 var (
 	ErrExplicitTracks               = errors.New("failed to get explicit tracks")
 	ErrCountTracksByGenre           = errors.New("failed to count tracks by genres")
 	ErrAlbumsWithMaxTracks          = errors.New("failed to get albums with min num of tracks")
+	ErrSearchTracks                 = errors.New("failed to search tracks")
 	ErrArtistsWithReleasedAlbumYear = errors.New("failed to get artists")
 	ErrUsersOlderThan               = errors.New("failed to get users older than specified")
+	ErrGetUser                      = errors.New("failed to get user")
+	ErrUpdateUser                   = errors.New("failed to update user")
+	ErrDeleteUser                   = errors.New("failed to delete user")
+	ErrGetPlaylist                  = errors.New("failed to get playlist")
+	ErrUpdatePlaylist               = errors.New("failed to update playlist")
+	ErrEditHistory                  = errors.New("failed to get edit history")
+	ErrDeletePlaylist               = errors.New("failed to delete playlist")
+	ErrRestore                      = errors.New("failed to restore deleted record")
+	ErrTrash                        = errors.New("failed to get deleted records")
+	ErrPurge                        = errors.New("failed to purge deleted records")
+	ErrArtistDiscography            = errors.New("failed to get artist discography")
+	ErrUserLibrary                  = errors.New("failed to get user library")
+	ErrImportUsers                  = errors.New("failed to import users")
+	ErrExportUsers                  = errors.New("failed to export users")
+	ErrExport                       = errors.New("failed to export records")
+	ErrDiscographies                = errors.New("failed to build discography documents")
+	ErrFindByMetadata               = errors.New("failed to find records by metadata")
+	ErrUpdateMetadata               = errors.New("failed to update metadata")
+	ErrImport                       = errors.New("failed to import records")
+	ErrAudit                        = errors.New("failed to write audit entry")
 
 	ErrTableAlreadyExists = errors.New("table already exists")
 	ErrStorageConnection  = errors.New("storage: can't connect to the database")
 	ErrNoRowsAffected     = errors.New("no rows affected")
 	ErrNotFound           = errors.New("not found")
+	ErrVersionConflict    = errors.New("record was modified concurrently")
+	ErrNotConfirmed       = errors.New("changes were not confirmed")
 )
 
+// Confirm подтверждает массовое изменение по его предпросмотру. Изменение
+// и запись в audit_log выполняются в транзакции, только если возвращено true.
+type Confirm func(preview *models.MutationPreview) bool
+
 type Storage interface {
 	BestExplicitTracks(ctx context.Context, limit int) ([]*models.Track, error)
 	CountTracksByGenre(ctx context.Context) ([]*models.GenreCount, error)
 	AlbumsWithMaxTracks(ctx context.Context, minNumOfTracks int) ([]*models.Album, error)
 	ArtistsWithReleasedAlbumYear(ctx context.Context, year int) ([]*models.Artist, error)
-	UsersOlderThan(ctx context.Context, age int) ([]*models.User, error)
+	UsersOlderThan(ctx context.Context, age int, q *pager.Query) (*pager.Page[*models.User], error)
 
-	TracksByGenre(ctx context.Context, genre string) ([]*models.Track, error)
+	TracksByGenre(ctx context.Context, genre string, q *pager.Query) (*pager.Page[*models.Track], error)
 	AlbumsWithTrackCounts(ctx context.Context, genre string) ([]*models.AlbumTrackCount, error)
+	SearchTracks(ctx context.Context, filter *models.TrackFilter) ([]*models.Track, int64, error)
 
 	AddUser(ctx context.Context, user *models.User) error
-	UpdateUserName(ctx context.Context, userID uuid.UUID, newName string) error
-	DeleteUser(ctx context.Context, userID uuid.UUID) error
+	User(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	UpdateUserName(ctx context.Context, userID uuid.UUID, version int64, newName string) error
+	DeleteUser(ctx context.Context, userID uuid.UUID, confirm Confirm) (int64, error)
+
+	Playlist(ctx context.Context, playlistID uuid.UUID) (*models.Playlist, error)
+	UpdatePlaylist(ctx context.Context, playlistID uuid.UUID, version int64, update *models.PlaylistUpdate) error
+	EditHistory(ctx context.Context, entity models.Entity, recordID uuid.UUID, limit int) ([]*models.Edit, error)
+
+	DeletePlaylist(ctx context.Context, playlistID uuid.UUID, confirm Confirm) (int64, error)
+	RestoreUser(ctx context.Context, userID uuid.UUID, confirm Confirm) (int64, error)
+	RestorePlaylist(ctx context.Context, playlistID uuid.UUID, confirm Confirm) (int64, error)
+	Trash(ctx context.Context, limit int) ([]*models.DeletedRecord, error)
+	PurgeDeleted(ctx context.Context, before time.Time, confirm Confirm) (int64, error)
 
 	AlbumsByArtist(ctx context.Context, artistID uuid.UUID) ([]*models.Album, error)
 
-	ExportUsersToJSON(ctx context.Context) ([]byte, error)
-	ImportUsers(ctx context.Context, users []*models.User) error
+	ArtistDiscography(ctx context.Context, artistID uuid.UUID) (*models.Artist, error)
+	UserLibrary(ctx context.Context, userID uuid.UUID) (*models.User, error)
+
+	ExportUsersToJSON(ctx context.Context, w io.Writer) (int, error)
+	ImportUsers(ctx context.Context, users []*models.User, confirm Confirm) (*models.ImportReport, error)
+
+	ExportEntity(ctx context.Context, entity models.Entity, format models.Format, w io.Writer) (int, error)
+	ImportEntity(ctx context.Context, entity models.Entity, r io.Reader, confirm Confirm) (*models.ImportReport, error)
+	ExportSubgraph(ctx context.Context, root models.Entity, id uuid.UUID, format models.Format, w io.Writer) (int, error)
+	ImportSubgraph(ctx context.Context, r io.Reader, confirm Confirm) (*models.ImportReport, error)
+
+	Discographies(ctx context.Context, artistID uuid.UUID, fn func(*models.DiscographyDocument) error) (int, error)
+	ImportDiscographies(ctx context.Context, documents []*models.DiscographyDocument, confirm Confirm) (*models.ImportReport, error)
+
+	FindByMetadata(ctx context.Context, filter *models.MetadataFilter) ([]*models.MetadataMatch, error)
+	UpdateMetadata(ctx context.Context, entity models.Entity, id uuid.UUID, path []string, value json.RawMessage) error
 }
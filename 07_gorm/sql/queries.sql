@@ -8,3 +8,52 @@ create table temp_users (
 );
 
 drop table temp_users;
+
+-- Уникальность связи альбом-исполнитель (нужна для идемпотентного импорта
+-- albums_by_artists: INSERT ... ON CONFLICT (album_id, artist_id) DO NOTHING)
+DELETE FROM albums_by_artists a
+USING albums_by_artists b
+WHERE a.ctid < b.ctid AND a.album_id = b.album_id AND a.artist_id = b.artist_id;
+
+ALTER TABLE albums_by_artists
+ADD CONSTRAINT unique_album_artist UNIQUE (album_id, artist_id);
+
+-- Метаданные исполнителей, альбомов и треков (JSONB) и GIN-индексы для @>, @? и @@
+ALTER TABLE artists ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE albums ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE tracks ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_artists_metadata ON artists USING gin (metadata jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_albums_metadata ON albums USING gin (metadata jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_tracks_metadata ON tracks USING gin (metadata jsonb_path_ops);
+
+-- Пример: треки с темпом выше 120 BPM и настроением "calm", имена участников записи
+SELECT id, name, jsonb_path_query_array(metadata, '$.credits[*].name') AS credits
+FROM tracks
+WHERE metadata @> '{"mood_tags": ["calm"]}' AND metadata @@ '$.bpm > 120';
+
+-- Мягкое удаление пользователей и плейлистов (GORM DeletedAt); частичные индексы
+-- для очистки корзины, основные запросы фильтруют deleted_at IS NULL
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE playlists ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_playlists_deleted_at ON playlists (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Версии записей для оптимистической блокировки и история изменений
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE playlists ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS edit_history (
+    id BIGSERIAL PRIMARY KEY,
+    entity VARCHAR(50) NOT NULL,
+    record_id UUID NOT NULL,
+    field VARCHAR(100) NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    version BIGINT NOT NULL,
+    edited_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT clock_timestamp(),
+    db_user VARCHAR(100) NOT NULL DEFAULT session_user
+);
+
+CREATE INDEX IF NOT EXISTS idx_edit_history_record ON edit_history (entity, record_id, edited_at);
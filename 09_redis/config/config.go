@@ -14,6 +14,8 @@ const (
 type Config struct {
 	Postres PostgresConfig
 	Redis   RedisConfig
+	Bench   BenchConfig
+	Metrics MetricsConfig
 }
 
 type PostgresConfig struct {
@@ -23,12 +25,78 @@ type PostgresConfig struct {
 	Host     string `env:"POSTGRES_HOST"`
 	Port     string `env:"POSTGRES_PORT"`
 	SSLMode  string `env:"POSTGRES_SSL_MODE"`
+
+	// Listen - поддерживать кэш по уведомлениям об изменениях (LISTEN/NOTIFY,
+	// нужны триггеры из sql/queries.sql)
+	Listen bool `env:"POSTGRES_LISTEN" env-default:"true"`
 }
 
 type RedisConfig struct {
 	Host       string        `env:"REDIS_HOST"`
 	Port       string        `env:"REDIS_PORT"`
 	Expiration time.Duration `env:"REDIS_EXPIRATION"`
+	Password   string        `env:"REDIS_PASSWORD"`
+	// DB - номер базы Redis
+	DB int `env:"REDIS_DB" env-default:"0"`
+	// TLS - подключаться по TLS; TLSInsecure - не проверять сертификат сервера
+	TLS         bool `env:"REDIS_TLS" env-default:"false"`
+	TLSInsecure bool `env:"REDIS_TLS_INSECURE" env-default:"false"`
+
+	DialTimeout  time.Duration `env:"REDIS_DIAL_TIMEOUT" env-default:"2s"`
+	ReadTimeout  time.Duration `env:"REDIS_READ_TIMEOUT" env-default:"500ms"`
+	WriteTimeout time.Duration `env:"REDIS_WRITE_TIMEOUT" env-default:"500ms"`
+	// PoolSize - соединений в пуле (0 - 10 на каждый GOMAXPROCS)
+	PoolSize int `env:"REDIS_POOL_SIZE" env-default:"0"`
+	// MaxRetries - повторов команды при сетевой ошибке (-1 - без повторов);
+	// пауза между повторами растет от MinRetryBackoff до MaxRetryBackoff
+	MaxRetries      int           `env:"REDIS_MAX_RETRIES" env-default:"2"`
+	MinRetryBackoff time.Duration `env:"REDIS_MIN_RETRY_BACKOFF" env-default:"8ms"`
+	MaxRetryBackoff time.Duration `env:"REDIS_MAX_RETRY_BACKOFF" env-default:"256ms"`
+	// BreakerFailures - ошибок подряд, после которых кэш обходится и данные читаются
+	// из Postgres (0 - автомат защиты отключен); BreakerCooldown - пауза до пробного запроса
+	BreakerFailures int           `env:"REDIS_BREAKER_FAILURES" env-default:"5"`
+	BreakerCooldown time.Duration `env:"REDIS_BREAKER_COOLDOWN" env-default:"10s"`
+
+	// LockTTL - срок аренды при загрузке значения в кэш (0 - без межпроцессной блокировки)
+	LockTTL time.Duration `env:"REDIS_LOCK_TTL" env-default:"5s"`
+	// LockWait - сколько ждать значения, загружаемого другим процессом
+	LockWait time.Duration `env:"REDIS_LOCK_WAIT" env-default:"1s"`
+	// StaleTTL - сколько отдавать устаревшее значение, пока оно обновляется в фоне
+	// (0 - stale-while-revalidate отключен)
+	StaleTTL time.Duration `env:"REDIS_STALE_TTL" env-default:"0s"`
+
+	// Strategy - стратегия кэширования топа треков: invalidate, write_through,
+	// sorted_set, refresh_ahead или two_level
+	Strategy string `env:"CACHE_STRATEGY" env-default:"invalidate"`
+	// Codec - кодек значений топа треков: json, gob, msgpack или protobuf
+	Codec string `env:"CACHE_CODEC" env-default:"json"`
+	// Compress - сжимать значения zstd
+	Compress bool `env:"CACHE_COMPRESS" env-default:"false"`
+	// LocalSize, LocalTTL - размер и срок жизни уровня в памяти (two_level)
+	LocalSize int           `env:"CACHE_LOCAL_SIZE" env-default:"1000"`
+	LocalTTL  time.Duration `env:"CACHE_LOCAL_TTL" env-default:"30s"`
+	// RefreshAhead - за сколько до истечения TTL обновлять значение (refresh_ahead)
+	RefreshAhead time.Duration `env:"REDIS_REFRESH_AHEAD" env-default:"2m"`
+}
+
+type BenchConfig struct {
+	// Duration - длительность замера без учета прогрева
+	Duration time.Duration `env:"BENCH_DURATION" env-default:"100s"`
+	// Warmup - сколько выполнять запросы до начала замера
+	Warmup time.Duration `env:"BENCH_WARMUP" env-default:"5s"`
+	// ReadRate - чтений топа в секунду на каждый источник (0 - без ограничения)
+	ReadRate float64 `env:"BENCH_READ_RATE" env-default:"2"`
+	// WriteRate - изменений данных в секунду (0 - без изменений)
+	WriteRate float64 `env:"BENCH_WRITE_RATE" env-default:"1"`
+	// Concurrency - число параллельных читателей каждого источника
+	Concurrency int `env:"BENCH_CONCURRENCY" env-default:"1"`
+	// Format - формат отчета: csv или json
+	Format string `env:"BENCH_FORMAT" env-default:"csv"`
+}
+
+type MetricsConfig struct {
+	// Addr - адрес HTTP-сервера с /metrics для Prometheus (пусто - не запускать)
+	Addr string `env:"METRICS_ADDR" env-default:":2112"`
 }
 
 func MustLoad() *Config {
@@ -2,17 +2,21 @@ package app
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 
 	"github.com/hahaclassic/databases/09_redis/config"
 	cacheredis "github.com/hahaclassic/databases/09_redis/internal/cache/redis"
 	"github.com/hahaclassic/databases/09_redis/internal/controller"
+	"github.com/hahaclassic/databases/09_redis/internal/metrics"
 	"github.com/hahaclassic/databases/09_redis/internal/service"
 	"github.com/hahaclassic/databases/09_redis/internal/storage/postgres"
+	"github.com/hahaclassic/databases/09_redis/pkg/breaker"
 )
 
 func Run(cfg *config.Config) {
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	db, err := postgres.New(ctx, &cfg.Postres)
 	if err != nil {
@@ -28,7 +32,41 @@ func Run(cfg *config.Config) {
 		return
 	}
 
-	s := service.New(cacheRedis, db)
+	defer cacheRedis.Close()
+
+	m := metrics.New()
+	m.RegisterKeyStats(cacheRedis.KeyStats)
+	m.RegisterCircuitBreaker(func() float64 { return float64(cacheRedis.BreakerState()) })
+
+	if cfg.Metrics.Addr != "" {
+		go func() {
+			if err := m.Serve(ctx, cfg.Metrics.Addr); err != nil {
+				slog.Warn("METRICS", "err", err)
+			}
+		}()
+	}
+
+	s, err := service.New(cacheRedis, cacheRedis, m.Storage(db), &cfg.Redis, &cfg.Bench, m)
+	if err != nil {
+		slog.Error("SERVICE", "err", err)
+		return
+	}
+
+	defer s.Close()
+
+	cacheRedis.OnStateChange(func(_, to breaker.State) {
+		if to == breaker.Closed {
+			go s.CacheRecovered(ctx)
+		}
+	})
+
+	if cfg.Postres.Listen {
+		go func() {
+			if err := s.WatchChanges(ctx, db); err != nil && !errors.Is(err, context.Canceled) {
+				slog.Warn("cache is kept only by own writes", "err", err)
+			}
+		}()
+	}
 
 	controller.New(s).Run(ctx)
 }
@@ -3,8 +3,16 @@ package cache
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/hahaclassic/databases/09_redis/internal/models"
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
@@ -12,16 +20,489 @@ var (
 	ErrSetData    = errors.New("cache: failed to set data")
 	ErrDeleteData = errors.New("cache: failed to delete data")
 	ErrCacheMiss  = errors.New("cache: cache miss")
+	ErrLock       = errors.New("cache: failed to acquire lock")
+	// ErrConflict - данные менялись во время перестроения
+	ErrConflict = errors.New("cache: concurrent changes during rebuild")
 
 	ErrConnection = errors.New("cache: failed to connect")
 )
 
-const (
-	Key = "top_10_most_streamed_tracks"
-)
+// Store - хранилище закодированных значений (Redis)
+type Store interface {
+	// Get возвращает ErrCacheMiss, если ключа нет
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set сохраняет значение; ttl = 0 - без срока жизни
+	Set(ctx context.Context, key string, data []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// PrefixDeleter - хранилище, которое может удалить все ключи с префиксом
+type PrefixDeleter interface {
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+type Options[T any] struct {
+	// Namespace - префикс ключей (например, "top_tracks")
+	Namespace string
+	// Version - версия формата значений: при ее изменении старые ключи
+	// перестают читаться и истекают сами
+	Version int
+	// Codec - кодек новых значений (по умолчанию JSON); значения читаются
+	// кодеком, которым они записаны
+	Codec Codec
+	// Compress - сжимать значения zstd
+	Compress bool
+	// TTL - срок жизни по умолчанию
+	TTL time.Duration
+	// KeyTTL - срок жизни для отдельного ключа (0 - TTL по умолчанию)
+	KeyTTL func(id string, value T) time.Duration
+
+	// Lock - межпроцессная блокировка загрузки (если Store реализует Locker)
+	Lock LockOptions
+	// StaleTTL - сколько после истечения TTL значение еще отдается (stale-while-revalidate),
+	// пока один воркер обновляет его в фоне (0 - режим отключен)
+	StaleTTL time.Duration
+	// RefreshAhead - значение, до устаревания которого осталось меньше RefreshAhead,
+	// обновляется в фоне при чтении (refresh-ahead, 0 - отключено)
+	RefreshAhead time.Duration
+	// Local - уровень в памяти процесса (nil - только Store); если Store реализует
+	// Broadcaster, изменения ключей рассылаются остальным экземплярам
+	Local *LocalOptions
+	// Metrics - получатель событий кэша (nil - события не учитываются)
+	Metrics Metrics
+}
+
+// Cache - типизированный кэш значений T в пространстве имен Namespace.
+// GetOrLoad объединяет одновременные загрузки одного ключа: внутри процесса
+// через singleflight, между процессами через аренду в Locker.
+type Cache[T any] struct {
+	store     Store
+	namespace string
+	prefix    string
+	codec     Codec
+	compress  bool
+	ttl       time.Duration
+	keyTTL    func(id string, value T) time.Duration
+
+	locker       Locker
+	lock         LockOptions
+	staleTTL     time.Duration
+	refreshAhead time.Duration
+
+	local       *local[T]
+	broadcaster Broadcaster
+	// subscription - подписка на инвалидацию локальных копий (закрывается Close)
+	subscription io.Closer
+
+	group singleflight.Group
+	// refreshing - ключи, обновляемые в фоне
+	refreshing sync.Map
+
+	hits    atomic.Int64
+	misses  atomic.Int64
+	metrics Metrics
+}
+
+// Stats - попадания и промахи GetOrLoad с момента создания кэша
+type Stats struct {
+	Hits   int64
+	Misses int64
+}
+
+// Sub - разница счетчиков (за период между двумя снимками)
+func (s Stats) Sub(prev Stats) Stats {
+	return Stats{Hits: s.Hits - prev.Hits, Misses: s.Misses - prev.Misses}
+}
+
+func (c *Cache[T]) Stats() Stats {
+	return Stats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+func New[T any](store Store, opts Options[T]) *Cache[T] {
+	codec := opts.Codec
+	if codec == nil {
+		codec = JSON
+	}
+
+	metrics := opts.Metrics
+	if metrics == nil {
+		metrics = nopMetrics{}
+	}
+
+	c := &Cache[T]{
+		store:        store,
+		namespace:    opts.Namespace,
+		prefix:       opts.Namespace + ":v" + strconv.Itoa(opts.Version) + ":",
+		codec:        codec,
+		compress:     opts.Compress,
+		ttl:          opts.TTL,
+		keyTTL:       opts.KeyTTL,
+		lock:         opts.Lock,
+		staleTTL:     opts.StaleTTL,
+		refreshAhead: opts.RefreshAhead,
+		metrics:      metrics,
+	}
+
+	if opts.Local != nil {
+		c.local = newLocal[T](opts.Local, func(reason string) {
+			metrics.Evict(opts.Namespace, reason)
+		})
+
+		if b, ok := store.(Broadcaster); ok {
+			// изменения рассылаются, даже если своя подписка не удалась
+			c.broadcaster = b
+			local := c.local
+			sub, err := b.Subscribe(context.Background(), invalidationChannel(c.prefix), func(message string) {
+				if key, ok := parseInvalidation(message); ok && key == clearAllKey {
+					local.clear()
+				} else if ok {
+					local.delete(key)
+				}
+			})
+			if err != nil {
+				// без подписки локальные копии не узнают об изменениях других экземпляров
+				slog.Warn("cache invalidation subscribe, local tier disabled", "prefix", c.prefix, "err", err)
+				c.local = nil
+			}
+			c.subscription = sub
+		}
+	}
+
+	if locker, ok := store.(Locker); ok && opts.Lock.TTL > 0 {
+		c.locker = locker
+		if c.lock.Retry <= 0 {
+			c.lock.Retry = defaultLockRetry
+		}
+	}
+
+	return c
+}
+
+// Close закрывает подписку на инвалидацию локальных копий
+func (c *Cache[T]) Close() error {
+	if c.subscription == nil {
+		return nil
+	}
+
+	return c.subscription.Close()
+}
+
+// Key - полный ключ в хранилище для id
+func (c *Cache[T]) Key(id string) string {
+	return c.prefix + id
+}
+
+// Get возвращает значение, в том числе устаревшее (в пределах StaleTTL)
+func (c *Cache[T]) Get(ctx context.Context, id string) (T, error) {
+	value, _, err := c.getEntry(ctx, id)
+
+	return value, err
+}
+
+// getEntry возвращает значение и время, до которого оно свежее (нулевое - без TTL)
+func (c *Cache[T]) getEntry(ctx context.Context, id string) (T, time.Time, error) {
+	key := c.Key(id)
+	var gen uint64
+	if c.local != nil {
+		if value, freshUntil, ok := c.local.get(key); ok {
+			c.metrics.LocalHit(c.namespace)
+			return value, freshUntil, nil
+		}
+		c.metrics.LocalMiss(c.namespace)
+		gen = c.local.generation()
+	}
+
+	var value T
+
+	start := time.Now()
+	data, err := c.store.Get(ctx, key)
+	if errors.Is(err, ErrCacheMiss) {
+		c.observe(OpGet, start, nil)
+	} else {
+		c.observe(OpGet, start, err)
+	}
+	if err != nil {
+		return value, time.Time{}, err
+	}
+
+	codec, freshUntil, payload, err := decodeEntry(data)
+	if err == nil {
+		err = codec.Unmarshal(payload, &value)
+	}
+	if err != nil {
+		var zero T
+		return zero, time.Time{}, c.discard(ctx, key, err)
+	}
+
+	if c.local != nil {
+		c.local.set(key, value, freshUntil, gen)
+	}
+
+	return value, freshUntil, nil
+}
+
+// discard удаляет значение, которое не удалось прочитать (поврежденное или
+// записанное в другом формате), и возвращает промах: значение будет загружено заново
+func (c *Cache[T]) discard(ctx context.Context, key string, err error) error {
+	slog.Warn("cache decode", "key", key, "err", err)
+
+	start := time.Now()
+	delErr := c.store.Delete(ctx, key)
+	c.observe(OpDelete, start, delErr)
+	if delErr != nil {
+		return fmt.Errorf("%w: %w", ErrGetData, errors.Join(err, delErr))
+	}
+
+	return fmt.Errorf("%w: %w: %w", ErrGetData, ErrCacheMiss, err)
+}
+
+// needsRefresh - нужно ли обновить значение в фоне: оно устарело (stale-while-revalidate)
+// или скоро устареет (refresh-ahead)
+func (c *Cache[T]) needsRefresh(freshUntil time.Time) bool {
+	if freshUntil.IsZero() {
+		return false
+	}
+
+	left := time.Until(freshUntil)
+
+	return (c.staleTTL > 0 && left < 0) || (c.refreshAhead > 0 && left < c.refreshAhead)
+}
+
+// Set сохраняет значение со сроком жизни KeyTTL (или TTL по умолчанию)
+func (c *Cache[T]) Set(ctx context.Context, id string, value T) error {
+	ttl := c.ttl
+	if c.keyTTL != nil {
+		if d := c.keyTTL(id, value); d > 0 {
+			ttl = d
+		}
+	}
+
+	return c.SetWithTTL(ctx, id, value, ttl)
+}
+
+func (c *Cache[T]) SetWithTTL(ctx context.Context, id string, value T, ttl time.Duration) error {
+	payload, err := c.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSetData, err)
+	}
+
+	// момент устаревания записывается в заголовок; в режиме stale-while-revalidate
+	// значение хранится дольше TTL
+	var freshUntil time.Time
+	if ttl > 0 {
+		freshUntil = time.Now().Add(ttl)
+		ttl += c.staleTTL
+	}
+
+	data, err := encodeEntry(c.codec, c.compress, freshUntil, payload)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSetData, err)
+	}
+
+	key := c.Key(id)
+	var gen uint64
+	if c.local != nil {
+		gen = c.local.generation()
+	}
+
+	start := time.Now()
+	err = c.store.Set(ctx, key, data, ttl)
+	c.observe(OpSet, start, err)
+	if err != nil {
+		// локальная копия могла устареть
+		if c.local != nil {
+			c.local.delete(key)
+		}
+		return err
+	}
+
+	// значение могло быть перезаписано другим экземпляром во время записи
+	if c.local != nil {
+		c.local.set(key, value, freshUntil, gen)
+	}
+
+	return c.broadcast(ctx, key)
+}
+
+func (c *Cache[T]) Delete(ctx context.Context, ids ...string) error {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, c.Key(id))
+	}
+
+	// локальные копии удаляются, даже если хранилище недоступно
+	if c.local != nil {
+		c.local.delete(keys...)
+	}
+
+	start := time.Now()
+	err := c.store.Delete(ctx, keys...)
+	c.observe(OpDelete, start, err)
+	if err != nil {
+		return err
+	}
+
+	return c.broadcast(ctx, keys...)
+}
+
+// Clear удаляет все значения пространства имен (если Store реализует PrefixDeleter)
+func (c *Cache[T]) Clear(ctx context.Context) error {
+	if c.local != nil {
+		c.local.clear()
+	}
+
+	deleter, ok := c.store.(PrefixDeleter)
+	if !ok {
+		return fmt.Errorf("%w: store can not delete keys by prefix", ErrDeleteData)
+	}
+
+	start := time.Now()
+	err := deleter.DeletePrefix(ctx, c.prefix)
+	c.observe(OpDelete, start, err)
+	if err != nil {
+		return err
+	}
+
+	return c.broadcast(ctx, clearAllKey)
+}
+
+// broadcast сообщает остальным экземплярам, что их локальные копии keys устарели
+func (c *Cache[T]) broadcast(ctx context.Context, keys ...string) error {
+	if c.broadcaster == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, key := range keys {
+		errs = append(errs, c.broadcaster.Publish(ctx, invalidationChannel(c.prefix), invalidationMessage(key)))
+	}
+
+	return errors.Join(errs...)
+}
+
+// GetOrLoad возвращает значение из кэша, а при промахе загружает его через load
+// и сохраняет. Устаревшее (или скоро устаревающее при RefreshAhead) значение
+// возвращается сразу, а обновляется в фоне. Если хранилище недоступно, значение
+// загружается через load без кэширования.
+func (c *Cache[T]) GetOrLoad(ctx context.Context, id string, load func(context.Context) (T, error)) (T, error) {
+	value, freshUntil, err := c.getEntry(ctx, id)
+	if err == nil {
+		c.hits.Add(1)
+		c.metrics.Hit(c.namespace)
+		if c.needsRefresh(freshUntil) {
+			c.refresh(ctx, id, load)
+		}
+		return value, nil
+	} else if !errors.Is(err, ErrCacheMiss) {
+		// хранилище недоступно: значение загружается в обход кэша
+		c.metrics.Fallback(c.namespace)
+		start := time.Now()
+		value, err = load(ctx)
+		c.observe(OpLoad, start, err)
+
+		return value, err
+	}
+	c.misses.Add(1)
+	c.metrics.Miss(c.namespace)
+
+	v, err, _ := c.group.Do(id, func() (any, error) {
+		return c.loadLocked(ctx, id, load, true)
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return v.(T), nil
+}
+
+// refresh обновляет значение в фоне; одновременно - не более одного обновления ключа
+func (c *Cache[T]) refresh(ctx context.Context, id string, load func(context.Context) (T, error)) {
+	if _, running := c.refreshing.LoadOrStore(id, struct{}{}); running {
+		return
+	}
+	c.metrics.Refresh(c.namespace)
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer c.refreshing.Delete(id)
+
+		_, err, _ := c.group.Do(id, func() (any, error) {
+			return c.loadLocked(ctx, id, load, false)
+		})
+		if err != nil && !errors.Is(err, ErrLock) {
+			slog.Warn("cache refresh", "key", c.Key(id), "err", err)
+		}
+	}()
+}
+
+// loadLocked загружает значение под арендой key. Если аренда занята другим
+// процессом: при wait ждет его результата (не дольше Lock.Wait, затем загружает
+// сам), иначе возвращает ErrLock.
+func (c *Cache[T]) loadLocked(ctx context.Context, id string, load func(context.Context) (T, error), wait bool) (T, error) {
+	if c.locker == nil {
+		return c.loadAndSet(ctx, id, load)
+	}
+
+	key, token := lockKey(c.Key(id)), uuid.NewString()
+
+	acquired, err := c.locker.Lock(ctx, key, token, c.lock.TTL)
+	if err != nil {
+		// без блокировки кэш продолжает работать как без координации
+		slog.Warn("cache lock", "key", key, "err", err)
+		return c.loadAndSet(ctx, id, load)
+	}
+
+	if acquired {
+		defer func() {
+			if err := c.locker.Unlock(context.WithoutCancel(ctx), key, token); err != nil {
+				slog.Warn("cache unlock", "key", key, "err", err)
+			}
+		}()
+
+		// значение могли обновить, пока аренду держал другой процесс
+		if value, freshUntil, err := c.getEntry(ctx, id); err == nil && !c.needsRefresh(freshUntil) {
+			return value, nil
+		}
+
+		return c.loadAndSet(ctx, id, load)
+	}
+
+	if !wait {
+		var zero T
+		return zero, ErrLock
+	}
+
+	for deadline := time.Now().Add(c.lock.Wait); time.Now().Before(deadline); {
+		if err := sleep(ctx, c.lock.Retry); err != nil {
+			var zero T
+			return zero, err
+		}
+
+		value, _, err := c.getEntry(ctx, id)
+		if err == nil {
+			return value, nil
+		} else if !errors.Is(err, ErrCacheMiss) {
+			break
+		}
+	}
+
+	return c.loadAndSet(ctx, id, load)
+}
+
+// loadAndSet загружает значение и сохраняет его в кэш; ошибка сохранения
+// не мешает вернуть загруженное значение
+func (c *Cache[T]) loadAndSet(ctx context.Context, id string, load func(context.Context) (T, error)) (T, error) {
+	start := time.Now()
+	value, err := load(ctx)
+	c.observe(OpLoad, start, err)
+	if err != nil {
+		return value, err
+	}
+
+	if err := c.Set(ctx, id, value); err != nil {
+		slog.Warn("cache set", "key", c.Key(id), "err", err)
+	}
 
-type Cache interface {
-	Get(ctx context.Context, key string) ([]*models.Track, error)
-	Set(ctx context.Context, key string, tracks []*models.Track) error
-	Delete(ctx context.Context, key string) error
+	return value, nil
 }
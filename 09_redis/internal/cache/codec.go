@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var (
+	ErrUnknownCodec    = errors.New("cache: unknown codec")
+	ErrUnsupportedType = errors.New("cache: type is not supported by codec")
+)
+
+// Codec кодирует значения кэша в байты. Tag записывается в каждое значение,
+// поэтому значения, записанные прежним кодеком, читаются и после смены кодека.
+type Codec interface {
+	Tag() byte
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	JSON     Codec = jsonCodec{}
+	Gob      Codec = gobCodec{}
+	MsgPack  Codec = msgpackCodec{}
+	Protobuf Codec = protobufCodec{}
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[byte]Codec{}
+)
+
+func init() {
+	for _, c := range []Codec{JSON, Gob, MsgPack, Protobuf} {
+		RegisterCodec(c)
+	}
+}
+
+// RegisterCodec делает кодек доступным для чтения значений и по имени
+func RegisterCodec(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	codecs[c.Tag()] = c
+}
+
+// Codecs - зарегистрированные кодеки
+func Codecs() []Codec {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	list := make([]Codec, 0, len(codecs))
+	for tag := range byte(compressedFlag) {
+		if c, ok := codecs[tag]; ok {
+			list = append(list, c)
+		}
+	}
+
+	return list
+}
+
+// CodecByName возвращает кодек по имени (json, gob, msgpack, protobuf)
+func CodecByName(name string) (Codec, error) {
+	for _, c := range Codecs() {
+		if c.Name() == name {
+			return c, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownCodec, name)
+}
+
+func codecByTag(tag byte) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	c, ok := codecs[tag]
+	if !ok {
+		return nil, fmt.Errorf("%w: tag %d", ErrUnknownCodec, tag)
+	}
+
+	return c, nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Tag() byte {
+	return 1
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Tag() byte {
+	return 2
+}
+
+func (gobCodec) Name() string {
+	return "gob"
+}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Tag() byte {
+	return 3
+}
+
+func (msgpackCodec) Name() string {
+	return "msgpack"
+}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"time"
+)
+
+// CodecStats - размер значения и среднее время кодирования/декодирования
+type CodecStats struct {
+	Codec      string
+	Compressed bool
+	// Size - размер значения в хранилище вместе с заголовком
+	Size   int
+	Encode time.Duration
+	Decode time.Duration
+}
+
+// MeasureCodec кодирует и декодирует value rounds раз так же, как Cache
+// (с заголовком и сжатием)
+func MeasureCodec[T any](codec Codec, compress bool, value T, rounds int) (*CodecStats, error) {
+	stats := &CodecStats{Codec: codec.Name(), Compressed: compress}
+
+	var data []byte
+	start := time.Now()
+	for range rounds {
+		payload, err := codec.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if data, err = encodeEntry(codec, compress, time.Time{}, payload); err != nil {
+			return nil, err
+		}
+	}
+	stats.Encode = time.Since(start) / time.Duration(rounds)
+	stats.Size = len(data)
+
+	start = time.Now()
+	for range rounds {
+		var decoded T
+
+		c, _, payload, err := decodeEntry(data)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = c.Unmarshal(payload, &decoded); err != nil {
+			return nil, err
+		}
+	}
+	stats.Decode = time.Since(start) / time.Duration(rounds)
+
+	return stats, nil
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Значение в хранилище: [тег кодека][время, до которого значение свежее (unix nano,
+// 0 - без срока)][данные]. Старший бит тега - данные сжаты zstd.
+const (
+	entryHeaderSize = 1 + 8
+	compressedFlag  = 0x80
+
+	// compressMinSize - меньшие значения не сжимаются (zstd их только увеличит)
+	compressMinSize = 256
+)
+
+var errShortEntry = errors.New("cache: entry is too short")
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+// initZstd создает общие кодировщик и декодировщик (EncodeAll/DecodeAll
+// безопасны для одновременного использования)
+func initZstd() error {
+	zstdOnce.Do(func() {
+		if zstdEncoder, zstdErr = zstd.NewWriter(nil); zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+
+	return zstdErr
+}
+
+func encodeEntry(codec Codec, compress bool, freshUntil time.Time, payload []byte) ([]byte, error) {
+	tag := codec.Tag()
+
+	if compress && len(payload) >= compressMinSize {
+		if err := initZstd(); err != nil {
+			return nil, err
+		}
+		payload = zstdEncoder.EncodeAll(payload, nil)
+		tag |= compressedFlag
+	}
+
+	data := make([]byte, entryHeaderSize, entryHeaderSize+len(payload))
+	data[0] = tag
+	if !freshUntil.IsZero() {
+		binary.BigEndian.PutUint64(data[1:], uint64(freshUntil.UnixNano()))
+	}
+
+	return append(data, payload...), nil
+}
+
+// decodeEntry возвращает кодек, которым записано значение, и распакованные данные
+func decodeEntry(data []byte) (codec Codec, freshUntil time.Time, payload []byte, err error) {
+	if len(data) < entryHeaderSize {
+		return nil, time.Time{}, nil, errShortEntry
+	}
+
+	tag := data[0]
+	if codec, err = codecByTag(tag &^ compressedFlag); err != nil {
+		return nil, time.Time{}, nil, err
+	}
+
+	if ts := binary.BigEndian.Uint64(data[1:]); ts != 0 {
+		freshUntil = time.Unix(0, int64(ts))
+	}
+
+	payload = data[entryHeaderSize:]
+	if tag&compressedFlag != 0 {
+		if err := initZstd(); err != nil {
+			return nil, time.Time{}, nil, err
+		}
+		if payload, err = zstdDecoder.DecodeAll(payload, nil); err != nil {
+			return nil, time.Time{}, nil, fmt.Errorf("zstd: %w", err)
+		}
+	}
+
+	return codec, freshUntil, payload, nil
+}
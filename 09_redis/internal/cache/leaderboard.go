@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/09_redis/internal/models"
+)
+
+// Period - период трендов
+type Period string
+
+const (
+	// PeriodDay - последние 24 часа (часовые корзины)
+	PeriodDay Period = "day"
+	// PeriodWeek - последние 7 дней (дневные корзины)
+	PeriodWeek Period = "week"
+)
+
+// Leaderboards - рейтинги в реальном времени: треки по жанрам, исполнители по сумме
+// прослушиваний и трендовые треки по приросту прослушиваний за день и неделю.
+// Рейтинги жанров и исполнителей строятся по Postgres (Rebuild) и до этого не
+// изменяются; тренды копятся только из обновлений и в Postgres не хранятся.
+type Leaderboards interface {
+	// Rebuild заменяет рейтинги жанров и исполнителей треками и их связями с
+	// исполнителями, которые возвращает load. Если рейтинги изменялись во время
+	// загрузки, она повторяется; ErrConflict - изменения не прекращались.
+	Rebuild(ctx context.Context, load func(context.Context) ([]*models.Track, []*models.TrackArtist, error)) error
+	// TrackAdded добавляет трек (или обновляет его, если он уже есть)
+	TrackAdded(ctx context.Context, track *models.Track) error
+	TrackRemoved(ctx context.Context, track *models.Track) error
+	// StreamsChanged задает прослушивания трека (track.StreamCount; было previous) и
+	// учитывает прирост в трендах на момент at. Одно и то же изменение, полученное
+	// несколькими экземплярами, учитывается один раз.
+	StreamsChanged(ctx context.Context, track *models.Track, previous int, at time.Time) error
+
+	// TopTracksByGenre, TopArtists и Trending возвращают ErrCacheMiss, если рейтинги
+	// не построены (данные треков хранятся вместе с рейтингами жанров)
+	TopTracksByGenre(ctx context.Context, genre string, n int) ([]*models.Track, error)
+	TopArtists(ctx context.Context, n int) ([]*models.ArtistStreams, error)
+	// Trending - n треков с наибольшим приростом прослушиваний за период до now
+	Trending(ctx context.Context, period Period, n int, now time.Time) ([]*models.TrendingTrack, error)
+
+	// Snapshot - рейтинги жанров и исполнителей целиком (для сверки с Postgres)
+	Snapshot(ctx context.Context) (*LeaderboardSnapshot, error)
+	// Clear удаляет рейтинги жанров и исполнителей (тренды остаются)
+	Clear(ctx context.Context) error
+}
+
+// LeaderboardSnapshot - прослушивания треков по жанрам и исполнителей
+type LeaderboardSnapshot struct {
+	Genres  map[string]map[uuid.UUID]int
+	Artists map[uuid.UUID]int
+}
+
+// NewLeaderboardSnapshot считает рейтинги жанров и исполнителей по трекам и их исполнителям
+func NewLeaderboardSnapshot(tracks []*models.Track, artists []*models.TrackArtist) *LeaderboardSnapshot {
+	snapshot := &LeaderboardSnapshot{
+		Genres:  make(map[string]map[uuid.UUID]int),
+		Artists: make(map[uuid.UUID]int),
+	}
+
+	streams := make(map[uuid.UUID]int, len(tracks))
+	for _, t := range tracks {
+		if snapshot.Genres[t.Genre] == nil {
+			snapshot.Genres[t.Genre] = make(map[uuid.UUID]int)
+		}
+		snapshot.Genres[t.Genre][t.ID] = t.StreamCount
+		streams[t.ID] = t.StreamCount
+	}
+
+	for _, a := range artists {
+		snapshot.Artists[a.ArtistID] += streams[a.TrackID]
+	}
+
+	return snapshot
+}
+
+// LeaderboardStore создает рейтинги в пространстве имен namespace
+type LeaderboardStore interface {
+	Leaderboards(namespace string) Leaderboards
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Broadcaster - рассылка сообщений всем экземплярам приложения (Redis pub/sub)
+type Broadcaster interface {
+	Publish(ctx context.Context, channel, message string) error
+	// Subscribe вызывает handle для каждого сообщения канала до закрытия подписки
+	Subscribe(ctx context.Context, channel string, handle func(message string)) (io.Closer, error)
+}
+
+// LocalOptions - локальный уровень кэша в памяти процесса перед Store
+type LocalOptions struct {
+	// Size - максимальное число значений (вытесняются давно не читавшиеся)
+	Size int
+	// TTL - сколько значение живет в памяти; ограничивает устаревание, если
+	// сообщение об инвалидации от другого экземпляра потеряно
+	TTL time.Duration
+}
+
+// instanceID отличает сообщения об инвалидации этого процесса от чужих
+var instanceID = uuid.NewString()
+
+type localEntry[T any] struct {
+	key        string
+	value      T
+	freshUntil time.Time
+	expires    time.Time
+}
+
+// local - LRU со сроком жизни записей; хранит уже декодированные значения
+type local[T any] struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	order *list.List
+	items map[string]*list.Element
+	// gen увеличивается при каждой инвалидации: значение, прочитанное из Store,
+	// сохраняется, только если за время чтения инвалидаций не было (иначе оно
+	// могло устареть, а сообщение о новом значении уже обработано)
+	gen uint64
+	// onEvict вызывается при вытеснении по размеру или сроку жизни
+	onEvict func(reason string)
+}
+
+func newLocal[T any](opts *LocalOptions, onEvict func(reason string)) *local[T] {
+	return &local[T]{
+		size:    max(opts.Size, 1),
+		ttl:     opts.TTL,
+		order:   list.New(),
+		items:   make(map[string]*list.Element),
+		onEvict: onEvict,
+	}
+}
+
+func (l *local[T]) get(key string) (value T, freshUntil time.Time, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.items[key]
+	if !ok {
+		return value, freshUntil, false
+	}
+
+	e := el.Value.(*localEntry[T])
+	if l.ttl > 0 && time.Now().After(e.expires) {
+		l.remove(el)
+		l.onEvict(EvictExpired)
+		return value, freshUntil, false
+	}
+	l.order.MoveToFront(el)
+
+	return e.value, e.freshUntil, true
+}
+
+// generation - счетчик инвалидаций; берется до чтения значения из Store
+func (l *local[T]) generation() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.gen
+}
+
+// set сохраняет значение, если с момента generation() = gen инвалидаций не было
+func (l *local[T]) set(key string, value T, freshUntil time.Time, gen uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.gen != gen {
+		return
+	}
+
+	e := &localEntry[T]{key: key, value: value, freshUntil: freshUntil, expires: time.Now().Add(l.ttl)}
+
+	if el, ok := l.items[key]; ok {
+		el.Value = e
+		l.order.MoveToFront(el)
+		return
+	}
+
+	l.items[key] = l.order.PushFront(e)
+	for l.order.Len() > l.size {
+		l.remove(l.order.Back())
+		l.onEvict(EvictSize)
+	}
+}
+
+func (l *local[T]) delete(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.gen++
+	for _, key := range keys {
+		if el, ok := l.items[key]; ok {
+			l.remove(el)
+		}
+	}
+}
+
+func (l *local[T]) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.gen++
+	l.order.Init()
+	clear(l.items)
+}
+
+func (l *local[T]) remove(el *list.Element) {
+	l.order.Remove(el)
+	delete(l.items, el.Value.(*localEntry[T]).key)
+}
+
+// invalidationChannel - канал сообщений об изменении ключей с префиксом prefix
+func invalidationChannel(prefix string) string {
+	return "invalidate:" + strings.TrimSuffix(prefix, ":")
+}
+
+// clearAllKey - ключ в сообщении об инвалидации, означающий все ключи пространства имен
+const clearAllKey = "*"
+
+// invalidationMessage - "<экземпляр> <ключ>"
+func invalidationMessage(key string) string {
+	return instanceID + " " + key
+}
+
+// parseInvalidation возвращает ключ из чужого сообщения (свои изменения уже учтены)
+func parseInvalidation(message string) (string, bool) {
+	instance, key, ok := strings.Cut(message, " ")
+
+	return key, ok && instance != instanceID
+}
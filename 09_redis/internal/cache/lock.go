@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Locker - распределенная блокировка (аренда) для координации загрузки
+// между процессами
+type Locker interface {
+	// Lock захватывает key на ttl, если он свободен; token нужен для освобождения
+	Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
+	// Unlock освобождает key, только если он захвачен с тем же token
+	Unlock(ctx context.Context, key, token string) error
+}
+
+type LockOptions struct {
+	// TTL - срок аренды (0 - межпроцессная блокировка отключена); должен
+	// превышать время загрузки значения
+	TTL time.Duration
+	// Wait - сколько ждать значения, загружаемого другим процессом, прежде
+	// чем загрузить его самостоятельно
+	Wait time.Duration
+	// Retry - интервал проверки кэша во время ожидания
+	Retry time.Duration
+}
+
+const defaultLockRetry = 20 * time.Millisecond
+
+func lockKey(key string) string {
+	return "lock:" + key
+}
+
+// sleep ждет d или отмены контекста
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package cache
+
+import "time"
+
+// Операции Store и загрузки для Metrics.Observe
+const (
+	OpGet    = "get"
+	OpSet    = "set"
+	OpDelete = "delete"
+	OpLoad   = "load"
+)
+
+// Причины вытеснения из уровня в памяти
+const (
+	EvictSize    = "size"
+	EvictExpired = "expired"
+)
+
+// Metrics - получатель событий кэша (например, метрики Prometheus)
+type Metrics interface {
+	// Hit, Miss - результат GetOrLoad
+	Hit(namespace string)
+	Miss(namespace string)
+	// LocalHit, LocalMiss - результат чтения уровня в памяти
+	LocalHit(namespace string)
+	LocalMiss(namespace string)
+	// Evict - значение вытеснено из уровня в памяти
+	Evict(namespace, reason string)
+	// Refresh - значение обновляется в фоне (stale-while-revalidate, refresh-ahead)
+	Refresh(namespace string)
+	// Fallback - хранилище недоступно, значение загружено в обход кэша
+	Fallback(namespace string)
+	// Observe - длительность операции op и ее ошибка (промах - не ошибка)
+	Observe(namespace, op string, d time.Duration, err error)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) Hit(string)                                   {}
+func (nopMetrics) Miss(string)                                  {}
+func (nopMetrics) LocalHit(string)                              {}
+func (nopMetrics) LocalMiss(string)                             {}
+func (nopMetrics) Evict(string, string)                         {}
+func (nopMetrics) Refresh(string)                               {}
+func (nopMetrics) Fallback(string)                              {}
+func (nopMetrics) Observe(string, string, time.Duration, error) {}
+
+// observe сообщает длительность операции, начатой в start
+func (c *Cache[T]) observe(op string, start time.Time, err error) {
+	c.metrics.Observe(c.namespace, op, time.Since(start), err)
+}
@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/09_redis/internal/models"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// protobufCodec кодирует списки треков в формат protobuf без сгенерированного кода:
+//
+//	message Track {
+//	    bytes id = 1;
+//	    string name = 2;
+//	    string album = 3;
+//	    bool explicit = 4;
+//	    int64 duration = 5;
+//	    string genre = 6;
+//	    int64 stream_count = 7;
+//	}
+//
+//	message Tracks {
+//	    repeated Track tracks = 1;
+//	}
+//
+// Другие типы не поддерживаются (ErrUnsupportedType).
+type protobufCodec struct{}
+
+const (
+	trackListField protowire.Number = 1
+
+	trackIDField          protowire.Number = 1
+	trackNameField        protowire.Number = 2
+	trackAlbumField       protowire.Number = 3
+	trackExplicitField    protowire.Number = 4
+	trackDurationField    protowire.Number = 5
+	trackGenreField       protowire.Number = 6
+	trackStreamCountField protowire.Number = 7
+)
+
+func (protobufCodec) Tag() byte {
+	return 4
+}
+
+func (protobufCodec) Name() string {
+	return "protobuf"
+}
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	tracks, ok := v.([]*models.Track)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+
+	var data, msg []byte
+	for _, t := range tracks {
+		msg = appendTrack(msg[:0], t)
+		data = protowire.AppendTag(data, trackListField, protowire.BytesType)
+		data = protowire.AppendBytes(data, msg)
+	}
+
+	return data, nil
+}
+
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	tracks, ok := v.(*[]*models.Track)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+
+	*tracks = (*tracks)[:0]
+
+	return consumeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num != trackListField || typ != protowire.BytesType {
+			return skipField(num, typ, b)
+		}
+
+		msg, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return n, nil
+		}
+
+		t, err := consumeTrack(msg)
+		if err != nil {
+			return 0, err
+		}
+		*tracks = append(*tracks, t)
+
+		return n, nil
+	})
+}
+
+func appendTrack(b []byte, t *models.Track) []byte {
+	b = protowire.AppendTag(b, trackIDField, protowire.BytesType)
+	b = protowire.AppendBytes(b, t.ID[:])
+	b = protowire.AppendTag(b, trackNameField, protowire.BytesType)
+	b = protowire.AppendString(b, t.Name)
+	b = protowire.AppendTag(b, trackAlbumField, protowire.BytesType)
+	b = protowire.AppendString(b, t.Album)
+	b = protowire.AppendTag(b, trackExplicitField, protowire.VarintType)
+	b = protowire.AppendVarint(b, protowire.EncodeBool(t.Explicit))
+	b = protowire.AppendTag(b, trackDurationField, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(t.Duration))
+	b = protowire.AppendTag(b, trackGenreField, protowire.BytesType)
+	b = protowire.AppendString(b, t.Genre)
+	b = protowire.AppendTag(b, trackStreamCountField, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(t.StreamCount))
+
+	return b
+}
+
+func consumeTrack(data []byte) (*models.Track, error) {
+	t := &models.Track{}
+
+	err := consumeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case typ == protowire.BytesType && num == trackIDField:
+			v, n := protowire.ConsumeBytes(b)
+			if n >= 0 {
+				id, err := uuid.FromBytes(v)
+				if err != nil {
+					return 0, err
+				}
+				t.ID = id
+			}
+			return n, nil
+
+		case typ == protowire.BytesType && (num == trackNameField || num == trackAlbumField || num == trackGenreField):
+			v, n := protowire.ConsumeString(b)
+			switch num {
+			case trackNameField:
+				t.Name = v
+			case trackAlbumField:
+				t.Album = v
+			default:
+				t.Genre = v
+			}
+			return n, nil
+
+		case typ == protowire.VarintType && (num == trackExplicitField || num == trackDurationField ||
+			num == trackStreamCountField):
+			v, n := protowire.ConsumeVarint(b)
+			switch num {
+			case trackExplicitField:
+				t.Explicit = protowire.DecodeBool(v)
+			case trackDurationField:
+				t.Duration = int(v)
+			default:
+				t.StreamCount = int(v)
+			}
+			return n, nil
+
+		default:
+			return skipField(num, typ, b)
+		}
+	})
+
+	return t, err
+}
+
+// consumeFields вызывает field для каждого поля сообщения; field возвращает
+// число прочитанных байт значения (отрицательное - ошибка protowire)
+func consumeFields(data []byte, field func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		n, err := field(num, typ, data)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+	}
+
+	return nil
+}
+
+// skipField пропускает неизвестное поле (совместимость с будущими версиями схемы)
+func skipField(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+	return protowire.ConsumeFieldValue(num, typ, b), nil
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/09_redis/internal/models"
+)
+
+// Ranking - треки, упорядоченные по числу прослушиваний и поддерживаемые
+// инкрементально (без пересчета топа в Postgres)
+type Ranking interface {
+	// Rebuild заменяет содержимое рейтинга треками, которые возвращает load. Если
+	// рейтинг изменялся во время загрузки, она повторяется; ErrConflict - изменения
+	// не прекращались.
+	Rebuild(ctx context.Context, load func(context.Context) ([]*models.Track, error)) error
+	// Add, Remove, SetStreams ничего не делают, пока рейтинг не построен. Значения
+	// задаются абсолютно, поэтому повторное применение изменения (каждым
+	// экземпляром, получившим уведомление) ничего не меняет.
+	Add(ctx context.Context, track *models.Track) error
+	Remove(ctx context.Context, id uuid.UUID) error
+	// SetStreams задает число прослушиваний трека, если он есть в рейтинге
+	SetStreams(ctx context.Context, id uuid.UUID, streams int) error
+	// Top возвращает n треков с наибольшим числом прослушиваний (пустой список для
+	// построенного пустого рейтинга) или ErrCacheMiss, если рейтинг не построен
+	Top(ctx context.Context, n int) ([]*models.Track, error)
+	// Clear удаляет рейтинг
+	Clear(ctx context.Context) error
+}
+
+// RankingStore создает рейтинги в пространстве имен namespace
+type RankingStore interface {
+	Ranking(namespace string) Ranking
+}
@@ -0,0 +1,80 @@
+package cacheredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/hahaclassic/databases/09_redis/internal/cache"
+	"github.com/hahaclassic/databases/09_redis/pkg/breaker"
+	"github.com/redis/go-redis/v9"
+)
+
+// breakerHook пропускает команды через автомат защиты: при открытом автомате
+// команда сразу завершается ошибкой cache.ErrConnection
+type breakerHook struct {
+	breaker *breaker.Breaker
+}
+
+func (h *breakerHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h *breakerHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ticket, err := h.allow()
+		if err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+
+		err = next(ctx, cmd)
+		h.record(ticket, err)
+
+		return err
+	}
+}
+
+func (h *breakerHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ticket, err := h.allow()
+		if err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+
+		err = next(ctx, cmds)
+		h.record(ticket, err)
+
+		return err
+	}
+}
+
+func (h *breakerHook) allow() (breaker.Ticket, error) {
+	ticket, err := h.breaker.Allow()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", cache.ErrConnection, err)
+	}
+
+	return ticket, nil
+}
+
+// record сообщает автомату результат команды: отсутствие ключа и ошибки,
+// которые вернул сам сервер (WRONGTYPE, NOSCRIPT и т.п.), означают, что Redis
+// доступен; отмена запроса вызывающим ничего не говорит о Redis
+func (h *breakerHook) record(ticket breaker.Ticket, err error) {
+	var redisErr redis.Error
+
+	switch {
+	case err == nil, errors.Is(err, redis.Nil), errors.As(err, &redisErr):
+		h.breaker.Success(ticket)
+	case errors.Is(err, context.Canceled):
+	default:
+		h.breaker.Failure(ticket)
+	}
+}
@@ -0,0 +1,502 @@
+package cacheredis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/09_redis/internal/cache"
+	"github.com/hahaclassic/databases/09_redis/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// Корзины трендов: часовые хранятся чуть дольше суток, дневные - чуть дольше недели
+const (
+	hourBucketTTL = 25 * time.Hour
+	dayBucketTTL  = 8 * 24 * time.Hour
+
+	hourBucketLayout = "2006010215"
+	dayBucketLayout  = "20060102"
+
+	// appliedTTL - сколько помнить изменения, уже учтенные в трендах (те же
+	// уведомления другие экземпляры получают почти одновременно)
+	appliedTTL = 10 * time.Minute
+)
+
+// incrArtistsLua изменяет на delta прослушивания исполнителей трека ARGV[1]
+// (KEYS[4] - рейтинг исполнителей, KEYS[5] - исполнители треков)
+const incrArtistsLua = `
+local artists = redis.call("HGET", KEYS[5], ARGV[1])
+if artists and delta ~= 0 then
+    for artist in string.gmatch(artists, "[^,]+") do
+        redis.call("ZINCRBY", KEYS[4], delta, artist)
+    end
+end
+return 1`
+
+// Скрипты изменяют рейтинги, только если они построены (KEYS[1] - отметка о построении),
+// а счетчик изменений KEYS[7] увеличивают всегда. KEYS[2] - рейтинг жанра,
+// KEYS[3] - треки (JSON); ARGV[1] - id трека.
+var (
+	// ARGV[2] - прослушивания, ARGV[3] - трек, ARGV[4] - жанр; KEYS[6] - список жанров
+	lbAddScript = redis.NewScript(`
+redis.call("INCR", KEYS[7])
+if redis.call("EXISTS", KEYS[1]) == 0 then
+    return 0
+end
+local delta = tonumber(ARGV[2]) - tonumber(redis.call("ZSCORE", KEYS[2], ARGV[1]) or 0)
+redis.call("ZADD", KEYS[2], ARGV[2], ARGV[1])
+redis.call("HSET", KEYS[3], ARGV[1], ARGV[3])
+redis.call("SADD", KEYS[6], ARGV[4])` + incrArtistsLua)
+
+	lbRemoveScript = redis.NewScript(`
+redis.call("INCR", KEYS[7])
+if redis.call("EXISTS", KEYS[1]) == 0 then
+    return 0
+end
+local score = redis.call("ZSCORE", KEYS[2], ARGV[1])
+if not score then
+    return 0
+end
+redis.call("ZREM", KEYS[2], ARGV[1])
+redis.call("HDEL", KEYS[3], ARGV[1])
+local delta = -tonumber(score)` + incrArtistsLua)
+
+	// ARGV[2] - прослушивания; исполнителям добавляется разница с прежним значением
+	lbStreamsScript = redis.NewScript(`
+redis.call("INCR", KEYS[7])
+if redis.call("EXISTS", KEYS[1]) == 0 then
+    return 0
+end
+local score = redis.call("ZSCORE", KEYS[2], ARGV[1])
+if not score then
+    return 0
+end
+local delta = tonumber(ARGV[2]) - tonumber(score)
+redis.call("ZADD", KEYS[2], ARGV[2], ARGV[1])` + incrArtistsLua)
+)
+
+// trendingScript добавляет прирост ARGV[2] трека ARGV[1] в часовую KEYS[2] и дневную
+// KEYS[3] корзины, только если отметка изменения KEYS[1] поставлена этим вызовом
+var trendingScript = redis.NewScript(`
+if not redis.call("SET", KEYS[1], 1, "NX", "EX", ARGV[3]) then
+    return 0
+end
+redis.call("ZINCRBY", KEYS[2], ARGV[2], ARGV[1])
+redis.call("EXPIRE", KEYS[2], ARGV[4])
+redis.call("ZINCRBY", KEYS[3], ARGV[2], ARGV[1])
+redis.call("EXPIRE", KEYS[3], ARGV[5])
+return 1`)
+
+// Leaderboards - рейтинги в Redis: sorted set на каждый жанр (id трека -> прослушивания),
+// sorted set исполнителей, hash треков (JSON), имен исполнителей и исполнителей треков
+// ("id,id"), корзины трендов по часам и дням
+type Leaderboards struct {
+	client    *redis.Client
+	namespace string
+	prefix    string
+}
+
+func (c *CacheRedis) Leaderboards(namespace string) cache.Leaderboards {
+	return &Leaderboards{
+		client:    c.client,
+		namespace: namespace,
+		prefix:    namespace + ":",
+	}
+}
+
+func (l *Leaderboards) key(parts ...string) string {
+	return l.prefix + strings.Join(parts, ":")
+}
+
+// keys - ключи, общие для всех скриптов
+func (l *Leaderboards) keys(genre string) []string {
+	return []string{l.key("built"), l.key("genre", genre), l.key("tracks"),
+		l.key("artists"), l.key("track_artists"), l.key("genres"), l.key("epoch")}
+}
+
+// boardKeys - ключи рейтингов жанров и исполнителей (без рейтингов отдельных жанров)
+func (l *Leaderboards) boardKeys() []string {
+	return []string{l.key("built"), l.key("genres"), l.key("tracks"), l.key("artists"),
+		l.key("artist_names"), l.key("track_artists")}
+}
+
+// genreKeys - рейтинги жанров из списка жанров
+func (l *Leaderboards) genreKeys(ctx context.Context, client redis.Cmdable) ([]string, error) {
+	genres, err := client.SMembers(ctx, l.key("genres")).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(genres))
+	for _, genre := range genres {
+		keys = append(keys, l.key("genre", genre))
+	}
+
+	return keys, nil
+}
+
+func (l *Leaderboards) Rebuild(ctx context.Context,
+	load func(context.Context) ([]*models.Track, []*models.TrackArtist, error)) error {
+	build := func(ctx context.Context, s *staging) error {
+		tracks, artists, err := load(ctx)
+		if err != nil {
+			return err
+		}
+
+		artistStreams := cache.NewLeaderboardSnapshot(tracks, artists).Artists
+
+		trackArtists := make(map[uuid.UUID][]string)
+		names := make(map[uuid.UUID]string)
+		for _, a := range artists {
+			trackArtists[a.TrackID] = append(trackArtists[a.TrackID], a.ArtistID.String())
+			names[a.ArtistID] = a.Name
+		}
+
+		staged := make(map[string]string)
+		for _, name := range []string{"built", "genres", "tracks", "artists", "artist_names", "track_artists"} {
+			staged[name] = s.key(l.key(name), name)
+		}
+		genreKey := func(genre string) string {
+			return s.key(l.key("genre", genre), "genre:"+genre)
+		}
+
+		_, err = l.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for start := 0; start < len(tracks); start += rebuildBatch {
+				batch := tracks[start:min(start+rebuildBatch, len(tracks))]
+
+				values := make([]any, 0, 2*len(batch))
+				for _, t := range batch {
+					data, err := json.Marshal(t)
+					if err != nil {
+						return err
+					}
+
+					pipe.ZAdd(ctx, genreKey(t.Genre), redis.Z{Score: float64(t.StreamCount), Member: t.ID.String()})
+					pipe.SAdd(ctx, staged["genres"], t.Genre)
+					values = append(values, t.ID.String(), data)
+				}
+				pipe.HSet(ctx, staged["tracks"], values...)
+			}
+
+			for id, ids := range trackArtists {
+				pipe.HSet(ctx, staged["track_artists"], id.String(), strings.Join(ids, ","))
+			}
+			for id, count := range artistStreams {
+				pipe.ZAdd(ctx, staged["artists"], redis.Z{Score: float64(count), Member: id.String()})
+				pipe.HSet(ctx, staged["artist_names"], id.String(), names[id])
+			}
+
+			pipe.Set(ctx, staged["built"], time.Now().Format(time.RFC3339), 0)
+			s.expire(ctx, pipe)
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("%w: %w", cache.ErrSetData, err)
+		}
+
+		return nil
+	}
+
+	// рейтинги жанров, которых нет в новом снимке, удаляются в той же транзакции
+	stale := func(ctx context.Context, tx *redis.Tx) ([]string, error) {
+		return l.genreKeys(ctx, tx)
+	}
+
+	return rebuild(ctx, l.client, l.namespace, l.key("epoch"), build, stale)
+}
+
+func (l *Leaderboards) TrackAdded(ctx context.Context, track *models.Track) error {
+	data, err := json.Marshal(track)
+	if err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrSetData, err)
+	}
+
+	err = lbAddScript.Run(ctx, l.client, l.keys(track.Genre),
+		track.ID.String(), track.StreamCount, data, track.Genre).Err()
+	if err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrSetData, err)
+	}
+
+	return nil
+}
+
+func (l *Leaderboards) TrackRemoved(ctx context.Context, track *models.Track) error {
+	err := lbRemoveScript.Run(ctx, l.client, l.keys(track.Genre), track.ID.String()).Err()
+	if err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrDeleteData, err)
+	}
+
+	return nil
+}
+
+func (l *Leaderboards) StreamsChanged(ctx context.Context, track *models.Track, previous int, at time.Time) error {
+	id := track.ID.String()
+
+	err := lbStreamsScript.Run(ctx, l.client, l.keys(track.Genre), id, track.StreamCount).Err()
+	if err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrSetData, err)
+	}
+
+	delta := track.StreamCount - previous
+	if delta == 0 {
+		return nil
+	}
+
+	// тренды копят прирост, поэтому изменение отмечается по (трек, было, стало)
+	// и учитывается только первым получившим его экземпляром
+	at = at.UTC()
+	keys := []string{
+		l.key("trending", "applied", fmt.Sprintf("%s:%d:%d", id, previous, track.StreamCount)),
+		l.key("trending", "h", at.Format(hourBucketLayout)),
+		l.key("trending", "d", at.Format(dayBucketLayout)),
+	}
+	err = trendingScript.Run(ctx, l.client, keys, id, delta, int(appliedTTL.Seconds()),
+		int(hourBucketTTL.Seconds()), int(dayBucketTTL.Seconds())).Err()
+	if err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrSetData, err)
+	}
+
+	return nil
+}
+
+// built возвращает ErrCacheMiss, если рейтинги не построены
+func (l *Leaderboards) built(ctx context.Context) error {
+	n, err := l.client.Exists(ctx, l.key("built")).Result()
+	if err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrGetData, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %w", cache.ErrGetData, cache.ErrCacheMiss)
+	}
+
+	return nil
+}
+
+func (l *Leaderboards) TopTracksByGenre(ctx context.Context, genre string, n int) ([]*models.Track, error) {
+	if err := l.built(ctx); err != nil {
+		return nil, err
+	}
+
+	scores, err := l.client.ZRevRangeWithScores(ctx, l.key("genre", genre), 0, int64(n-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+	}
+
+	tracks, err := l.tracks(ctx, scores)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.Track, 0, len(tracks))
+	for _, t := range tracks {
+		t.Track.StreamCount = t.Streams
+		result = append(result, t.Track)
+	}
+
+	return result, nil
+}
+
+func (l *Leaderboards) TopArtists(ctx context.Context, n int) ([]*models.ArtistStreams, error) {
+	if err := l.built(ctx); err != nil {
+		return nil, err
+	}
+
+	scores, err := l.client.ZRevRangeWithScores(ctx, l.key("artists"), 0, int64(n-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+	}
+	if len(scores) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(scores))
+	for _, z := range scores {
+		ids = append(ids, z.Member.(string))
+	}
+
+	names, err := l.client.HMGet(ctx, l.key("artist_names"), ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+	}
+
+	artists := make([]*models.ArtistStreams, 0, len(scores))
+	for i, z := range scores {
+		id, err := uuid.Parse(ids[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+		}
+
+		name, _ := names[i].(string)
+		artists = append(artists, &models.ArtistStreams{ID: id, Name: name, StreamCount: int(z.Score)})
+	}
+
+	return artists, nil
+}
+
+func (l *Leaderboards) Trending(ctx context.Context, period cache.Period, n int, now time.Time) ([]*models.TrendingTrack, error) {
+	if err := l.built(ctx); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+
+	now = now.UTC()
+	switch period {
+	case cache.PeriodDay:
+		for i := range 24 {
+			keys = append(keys, l.key("trending", "h", now.Add(-time.Duration(i)*time.Hour).Format(hourBucketLayout)))
+		}
+	case cache.PeriodWeek:
+		for i := range 7 {
+			keys = append(keys, l.key("trending", "d", now.AddDate(0, 0, -i).Format(dayBucketLayout)))
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown period %q", cache.ErrGetData, period)
+	}
+
+	// ZUNION возвращает всех участников по возрастанию суммы
+	scores, err := l.client.ZUnionWithScores(ctx, redis.ZStore{Keys: keys}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+	}
+	slices.Reverse(scores)
+
+	tracks, err := l.tracks(ctx, scores[:min(n, len(scores))])
+	if err != nil {
+		return nil, err
+	}
+
+	return tracks, l.streamCounts(ctx, tracks)
+}
+
+// streamCounts заменяет прослушивания треков (в JSON - на момент добавления или
+// перестроения) текущими из рейтингов жанров
+func (l *Leaderboards) streamCounts(ctx context.Context, tracks []*models.TrendingTrack) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+
+	scores := make([]*redis.FloatCmd, len(tracks))
+	// ошибки проверяются по командам: redis.Nil - трека нет в рейтинге жанра
+	_, _ = l.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, t := range tracks {
+			scores[i] = pipe.ZScore(ctx, l.key("genre", t.Track.Genre), t.Track.ID.String())
+		}
+		return nil
+	})
+
+	for i, t := range tracks {
+		score, err := scores[i].Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("%w: %w", cache.ErrGetData, err)
+		}
+		t.Track.StreamCount = int(score)
+	}
+
+	return nil
+}
+
+// tracks дополняет участников рейтинга данными треков; удаленные треки пропускаются
+func (l *Leaderboards) tracks(ctx context.Context, scores []redis.Z) ([]*models.TrendingTrack, error) {
+	if len(scores) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(scores))
+	for _, z := range scores {
+		ids = append(ids, z.Member.(string))
+	}
+
+	values, err := l.client.HMGet(ctx, l.key("tracks"), ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+	}
+
+	tracks := make([]*models.TrendingTrack, 0, len(values))
+	for i, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		track := &models.Track{}
+		if err := json.Unmarshal([]byte(data), track); err != nil {
+			return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+		}
+		tracks = append(tracks, &models.TrendingTrack{Track: track, Streams: int(scores[i].Score)})
+	}
+
+	return tracks, nil
+}
+
+func (l *Leaderboards) Snapshot(ctx context.Context) (*cache.LeaderboardSnapshot, error) {
+	if err := l.built(ctx); err != nil {
+		return nil, err
+	}
+
+	genres, err := l.client.SMembers(ctx, l.key("genres")).Result()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+	}
+
+	snapshot := &cache.LeaderboardSnapshot{Genres: make(map[string]map[uuid.UUID]int, len(genres))}
+	for _, genre := range genres {
+		if snapshot.Genres[genre], err = l.scores(ctx, l.key("genre", genre)); err != nil {
+			return nil, err
+		}
+	}
+
+	if snapshot.Artists, err = l.scores(ctx, l.key("artists")); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// scores - весь sorted set key
+func (l *Leaderboards) scores(ctx context.Context, key string) (map[uuid.UUID]int, error) {
+	zs, err := l.client.ZRangeWithScores(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+	}
+
+	scores := make(map[uuid.UUID]int, len(zs))
+	for _, z := range zs {
+		id, err := uuid.Parse(z.Member.(string))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+		}
+		scores[id] = int(z.Score)
+	}
+
+	return scores, nil
+}
+
+func (l *Leaderboards) Clear(ctx context.Context) error {
+	genres, err := l.genreKeys(ctx, l.client)
+	if err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrDeleteData, err)
+	}
+
+	// счетчик изменений увеличивается, чтобы идущее перестроение не вернуло
+	// удаленные рейтинги
+	_, err = l.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, append(l.boardKeys(), genres...)...)
+		pipe.Incr(ctx, l.key("epoch"))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrDeleteData, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package cacheredis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/09_redis/internal/cache"
+	"github.com/hahaclassic/databases/09_redis/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// rebuildBatch - треков в одной команде ZADD/HSET при перестроении
+const rebuildBatch = 1000
+
+// Скрипты изменяют рейтинг, только если он построен (KEYS[1] - отметка о построении):
+// иначе частичный рейтинг выглядел бы как полный. Счетчик изменений KEYS[2]
+// увеличивается всегда. KEYS[3] - sorted set, KEYS[4] - треки (JSON); ARGV[1] - id трека.
+var (
+	// ARGV[2] - прослушивания, ARGV[3] - трек
+	addScript = redis.NewScript(`
+redis.call("INCR", KEYS[2])
+if redis.call("EXISTS", KEYS[1]) == 1 then
+    redis.call("ZADD", KEYS[3], ARGV[2], ARGV[1])
+    redis.call("HSET", KEYS[4], ARGV[1], ARGV[3])
+end
+return 0`)
+
+	removeScript = redis.NewScript(`
+redis.call("INCR", KEYS[2])
+redis.call("ZREM", KEYS[3], ARGV[1])
+redis.call("HDEL", KEYS[4], ARGV[1])
+return 0`)
+
+	// ARGV[2] - прослушивания (XX - только для трека, который уже есть в рейтинге)
+	setStreamsScript = redis.NewScript(`
+redis.call("INCR", KEYS[2])
+redis.call("ZADD", KEYS[3], "XX", ARGV[2], ARGV[1])
+return 0`)
+)
+
+// Ranking - рейтинг треков: sorted set id -> stream_count и hash id -> трек (JSON)
+type Ranking struct {
+	client    *redis.Client
+	namespace string
+	// scores, tracks - ключи sorted set и hash; built - отметка о построении,
+	// epoch - счетчик изменений
+	scores string
+	tracks string
+	built  string
+	epoch  string
+}
+
+func (c *CacheRedis) Ranking(namespace string) cache.Ranking {
+	return &Ranking{
+		client:    c.client,
+		namespace: namespace,
+		scores:    namespace + ":streams",
+		tracks:    namespace + ":tracks",
+		built:     namespace + ":built",
+		epoch:     namespace + ":epoch",
+	}
+}
+
+func (r *Ranking) keys() []string {
+	return []string{r.built, r.epoch, r.scores, r.tracks}
+}
+
+func (r *Ranking) Rebuild(ctx context.Context, load func(context.Context) ([]*models.Track, error)) error {
+	return rebuild(ctx, r.client, r.namespace, r.epoch, func(ctx context.Context, s *staging) error {
+		tracks, err := load(ctx)
+		if err != nil {
+			return err
+		}
+
+		scores, hash, built := s.key(r.scores, "streams"), s.key(r.tracks, "tracks"), s.key(r.built, "built")
+
+		_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for start := 0; start < len(tracks); start += rebuildBatch {
+				batch := tracks[start:min(start+rebuildBatch, len(tracks))]
+
+				members := make([]redis.Z, 0, len(batch))
+				values := make([]any, 0, 2*len(batch))
+				for _, t := range batch {
+					data, err := json.Marshal(t)
+					if err != nil {
+						return err
+					}
+
+					members = append(members, redis.Z{Score: float64(t.StreamCount), Member: t.ID.String()})
+					values = append(values, t.ID.String(), data)
+				}
+
+				pipe.ZAdd(ctx, scores, members...)
+				pipe.HSet(ctx, hash, values...)
+			}
+
+			pipe.Set(ctx, built, time.Now().Format(time.RFC3339), 0)
+			s.expire(ctx, pipe)
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("%w: %w", cache.ErrSetData, err)
+		}
+
+		return nil
+	}, nil)
+}
+
+func (r *Ranking) Add(ctx context.Context, track *models.Track) error {
+	data, err := json.Marshal(track)
+	if err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrSetData, err)
+	}
+
+	err = addScript.Run(ctx, r.client, r.keys(), track.ID.String(), track.StreamCount, data).Err()
+	if err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrSetData, err)
+	}
+
+	return nil
+}
+
+func (r *Ranking) Remove(ctx context.Context, id uuid.UUID) error {
+	if err := removeScript.Run(ctx, r.client, r.keys(), id.String()).Err(); err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrDeleteData, err)
+	}
+
+	return nil
+}
+
+func (r *Ranking) SetStreams(ctx context.Context, id uuid.UUID, streams int) error {
+	if err := setStreamsScript.Run(ctx, r.client, r.keys(), id.String(), streams).Err(); err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrSetData, err)
+	}
+
+	return nil
+}
+
+func (r *Ranking) Top(ctx context.Context, n int) ([]*models.Track, error) {
+	var built *redis.IntCmd
+	var top *redis.ZSliceCmd
+	if _, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		built = pipe.Exists(ctx, r.built)
+		top = pipe.ZRevRangeWithScores(ctx, r.scores, 0, int64(n-1))
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+	}
+	if built.Val() == 0 {
+		return nil, fmt.Errorf("%w: %w", cache.ErrGetData, cache.ErrCacheMiss)
+	}
+
+	scores := top.Val()
+	if len(scores) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(scores))
+	for _, z := range scores {
+		ids = append(ids, z.Member.(string))
+	}
+
+	values, err := r.client.HMGet(ctx, r.tracks, ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+	}
+
+	tracks := make([]*models.Track, 0, len(values))
+	for i, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			// трек удален между ZREVRANGE и HMGET
+			continue
+		}
+
+		track := &models.Track{}
+		if err := json.Unmarshal([]byte(data), track); err != nil {
+			return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+		}
+		// актуальное число прослушиваний хранится только в sorted set
+		track.StreamCount = int(scores[i].Score)
+		tracks = append(tracks, track)
+	}
+
+	return tracks, nil
+}
+
+func (r *Ranking) Clear(ctx context.Context) error {
+	// счетчик изменений увеличивается, чтобы идущее перестроение не вернуло
+	// удаленный рейтинг
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, r.built, r.scores, r.tracks)
+		pipe.Incr(ctx, r.epoch)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrDeleteData, err)
+	}
+
+	return nil
+}
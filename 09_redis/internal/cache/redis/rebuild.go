@@ -0,0 +1,163 @@
+package cacheredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/09_redis/internal/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// Перестроение рейтингов: данные пишутся во временные ключи, которые затем
+// переименовываются в рабочие в одной транзакции MULTI. Скрипты изменений
+// увеличивают счетчик изменений, даже пока рейтинг не построен; если он изменился
+// с начала загрузки данных, снимок мог пропустить изменения и перестроение повторяется.
+const (
+	// rebuildAttempts - попыток перестроения при изменениях во время загрузки
+	rebuildAttempts = 3
+	// stagingTTL - срок жизни временных ключей (если перестроение прервано)
+	stagingTTL = 10 * time.Minute
+)
+
+// staging - временные ключи одного перестроения
+type staging struct {
+	prefix string
+	// renames - временный ключ -> рабочий
+	renames map[string]string
+}
+
+func newStaging(namespace string) *staging {
+	return &staging{
+		prefix:  namespace + ":rebuild:" + uuid.NewString() + ":",
+		renames: make(map[string]string),
+	}
+}
+
+// key - временный ключ для рабочего ключа target
+func (s *staging) key(target, name string) string {
+	key := s.prefix + name
+	s.renames[key] = target
+
+	return key
+}
+
+// expire ограничивает срок жизни временных ключей (вызывается после записи в них)
+func (s *staging) expire(ctx context.Context, pipe redis.Pipeliner) {
+	for key := range s.renames {
+		pipe.Expire(ctx, key, stagingTTL)
+	}
+}
+
+func (s *staging) drop(ctx context.Context, client *redis.Client) {
+	keys := make([]string, 0, len(s.renames))
+	for key := range s.renames {
+		keys = append(keys, key)
+	}
+	// временные ключи истекут сами, если удалить их не удалось
+	client.Del(context.WithoutCancel(ctx), keys...)
+}
+
+// epoch - счетчик изменений рейтинга (0, если изменений не было)
+func epoch(ctx context.Context, client redis.Cmdable, key string) (int64, error) {
+	n, err := client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+
+	return n, err
+}
+
+// rebuild повторяет build, пока он не подменит рейтинг (не больше rebuildAttempts раз).
+// build загружает данные и записывает их во временные ключи staging.
+func rebuild(ctx context.Context, client *redis.Client, namespace, epochKey string,
+	build func(ctx context.Context, s *staging) error,
+	stale func(ctx context.Context, tx *redis.Tx) ([]string, error)) error {
+	for range rebuildAttempts {
+		since, err := epoch(ctx, client, epochKey)
+		if err != nil {
+			return fmt.Errorf("%w: %w", cache.ErrSetData, err)
+		}
+
+		s := newStaging(namespace)
+		if err := build(ctx, s); err != nil {
+			s.drop(ctx, client)
+			return err
+		}
+
+		swapped, err := swap(ctx, client, epochKey, since, s, stale)
+		if err != nil || !swapped {
+			s.drop(ctx, client)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", cache.ErrSetData, err)
+		}
+		if swapped {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %w", cache.ErrSetData, cache.ErrConflict)
+}
+
+// swap атомарно заменяет рабочие ключи временными, если счетчик изменений все еще
+// равен since. Рабочие ключи, которых нет среди временных (пустой рейтинг, исчезнувший
+// жанр), и ключи stale удаляются.
+func swap(ctx context.Context, client *redis.Client, epochKey string, since int64, s *staging,
+	stale func(ctx context.Context, tx *redis.Tx) ([]string, error)) (bool, error) {
+	swapped := false
+
+	err := client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := epoch(ctx, tx, epochKey)
+		if err != nil || current != since {
+			return err
+		}
+
+		var targets []string
+		if stale != nil {
+			if targets, err = stale(ctx, tx); err != nil {
+				return err
+			}
+		}
+		for _, target := range s.renames {
+			targets = append(targets, target)
+		}
+
+		// пустые коллекции Redis не хранит: переименовываются только существующие ключи
+		sources := make([]string, 0, len(s.renames))
+		for key := range s.renames {
+			sources = append(sources, key)
+		}
+		exists := make([]*redis.IntCmd, len(sources))
+		if _, err := tx.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, key := range sources {
+				exists[i] = pipe.Exists(ctx, key)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, targets...)
+			for i, key := range sources {
+				if exists[i].Val() == 0 {
+					continue
+				}
+				pipe.Rename(ctx, key, s.renames[key])
+				pipe.Persist(ctx, s.renames[key])
+			}
+			return nil
+		})
+		swapped = err == nil
+
+		return err
+	}, epochKey)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	}
+
+	return swapped, err
+}
@@ -2,74 +2,216 @@ package cacheredis
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hahaclassic/databases/09_redis/config"
 	"github.com/hahaclassic/databases/09_redis/internal/cache"
-	"github.com/hahaclassic/databases/09_redis/internal/models"
+	"github.com/hahaclassic/databases/09_redis/pkg/breaker"
 	"github.com/redis/go-redis/v9"
 )
 
+// CacheRedis - cache.Store в Redis
 type CacheRedis struct {
-	config *config.RedisConfig
 	client *redis.Client
+	// breaker - автомат защиты (nil - отключен)
+	breaker *breaker.Breaker
 }
 
+// New подключается к Redis. Если Redis недоступен, клиент все равно создается:
+// с автоматом защиты кэш обходится, пока Redis не восстановится.
 func New(ctx context.Context, cfg *config.RedisConfig) (*CacheRedis, error) {
-	cli := redis.NewClient(&redis.Options{
-		Addr: net.JoinHostPort(cfg.Host, cfg.Port),
-	})
+	opts := &redis.Options{
+		Addr:            net.JoinHostPort(cfg.Host, cfg.Port),
+		Password:        cfg.Password,
+		DB:              cfg.DB,
+		DialTimeout:     cfg.DialTimeout,
+		ReadTimeout:     cfg.ReadTimeout,
+		WriteTimeout:    cfg.WriteTimeout,
+		PoolSize:        cfg.PoolSize,
+		MaxRetries:      cfg.MaxRetries,
+		MinRetryBackoff: cfg.MinRetryBackoff,
+		MaxRetryBackoff: cfg.MaxRetryBackoff,
+		// таймауты ctx важнее ReadTimeout/WriteTimeout
+		ContextTimeoutEnabled: true,
+	}
+	if cfg.TLS {
+		opts.TLSConfig = &tls.Config{
+			ServerName:         cfg.Host,
+			InsecureSkipVerify: cfg.TLSInsecure,
+			MinVersion:         tls.VersionTLS12,
+		}
+	}
 
-	if err := cli.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("%w: %w", cache.ErrConnection, err)
+	c := &CacheRedis{client: redis.NewClient(opts)}
+
+	if cfg.BreakerFailures > 0 {
+		c.breaker = breaker.New(cfg.BreakerFailures, cfg.BreakerCooldown)
+		c.breaker.OnStateChange(func(from, to breaker.State) {
+			slog.Warn("redis circuit breaker", "from", from, "to", to)
+		})
+		c.client.AddHook(&breakerHook{breaker: c.breaker})
 	}
 
-	return &CacheRedis{
-		client: cli,
-		config: cfg,
-	}, nil
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		if c.breaker == nil {
+			c.client.Close()
+			return nil, fmt.Errorf("%w: %w", cache.ErrConnection, err)
+		}
+		slog.Warn("redis is unavailable, reads fall back to Postgres", "err", err)
+	}
+
+	return c, nil
+}
+
+// OnStateChange добавляет обработчик смены состояния автомата защиты
+func (c *CacheRedis) OnStateChange(fn func(from, to breaker.State)) {
+	if c.breaker != nil {
+		c.breaker.OnStateChange(fn)
+	}
+}
+
+// BreakerState - состояние автомата защиты (Closed, если он отключен)
+func (c *CacheRedis) BreakerState() breaker.State {
+	if c.breaker == nil {
+		return breaker.Closed
+	}
+
+	return c.breaker.State()
+}
+
+func (c *CacheRedis) Close() error {
+	return c.client.Close()
 }
 
-func (c *CacheRedis) Get(ctx context.Context, key string) ([]*models.Track, error) {
-	var tracks []*models.Track
+func (c *CacheRedis) Get(ctx context.Context, key string) ([]byte, error) {
 	data, err := c.client.Get(ctx, key).Bytes()
 	if errors.Is(err, redis.Nil) {
-		return nil, fmt.Errorf("%w: %w", cache.ErrSetData, cache.ErrCacheMiss)
+		return nil, fmt.Errorf("%w: %w", cache.ErrGetData, cache.ErrCacheMiss)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
 	}
 
-	err = json.Unmarshal(data, &tracks)
+	return data, nil
+}
+
+func (c *CacheRedis) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	err := c.client.Set(ctx, key, data, ttl).Err()
 	if err != nil {
-		return nil, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+		return fmt.Errorf("%w: %w", cache.ErrSetData, err)
 	}
 
-	return tracks, nil
+	return nil
 }
 
-func (c *CacheRedis) Set(ctx context.Context, key string, tracks []*models.Track) error {
-	data, err := json.Marshal(tracks)
-	if err != nil {
-		return fmt.Errorf("%w: %w", cache.ErrSetData, err)
+func (c *CacheRedis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
 	}
 
-	err = c.client.Set(ctx, key, data, c.config.Expiration).Err()
+	err := c.client.Del(ctx, keys...).Err()
 	if err != nil {
-		return fmt.Errorf("%w: %w", cache.ErrSetData, err)
+		return fmt.Errorf("%w: %w", cache.ErrDeleteData, err)
 	}
 
 	return nil
 }
 
-func (c *CacheRedis) Delete(ctx context.Context, key string) error {
-	err := c.client.Del(ctx, key).Err()
+// scanBatch - ключей в одном SCAN при удалении по префиксу
+const scanBatch = 1000
+
+var globReplacer = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// DeletePrefix удаляет ключи с префиксом prefix: сначала все ключи собираются
+// SCAN (удаление во время обхода могло бы сдвинуть курсор), затем удаляются UNLINK
+func (c *CacheRedis) DeletePrefix(ctx context.Context, prefix string) error {
+	var keys []string
+
+	iter := c.client.Scan(ctx, 0, globReplacer.Replace(prefix)+"*", scanBatch).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrDeleteData, err)
+	}
+
+	for start := 0; start < len(keys); start += scanBatch {
+		if err := c.client.Unlink(ctx, keys[start:min(start+scanBatch, len(keys))]...).Err(); err != nil {
+			return fmt.Errorf("%w: %w", cache.ErrDeleteData, err)
+		}
+	}
+
+	return nil
+}
+
+// unlockScript удаляет ключ блокировки, только если он все еще принадлежит владельцу
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+    return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+func (c *CacheRedis) Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	ok, err := c.client.SetNX(ctx, key, token, ttl).Result()
 	if err != nil {
+		return false, fmt.Errorf("%w: %w", cache.ErrLock, err)
+	}
+
+	return ok, nil
+}
+
+func (c *CacheRedis) Unlock(ctx context.Context, key, token string) error {
+	if err := unlockScript.Run(ctx, c.client, []string{key}, token).Err(); err != nil {
 		return fmt.Errorf("%w: %w", cache.ErrDeleteData, err)
 	}
 
 	return nil
 }
+
+func (c *CacheRedis) Publish(ctx context.Context, channel, message string) error {
+	if err := c.client.Publish(ctx, channel, message).Err(); err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrSetData, err)
+	}
+
+	return nil
+}
+
+// Subscribe подписывается на channel; сообщения обрабатываются в отдельной
+// горутине до закрытия подписки (go-redis переподключает подписку сам)
+func (c *CacheRedis) Subscribe(ctx context.Context, channel string, handle func(message string)) (io.Closer, error) {
+	sub := c.client.Subscribe(ctx, channel)
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, fmt.Errorf("%w: %w", cache.ErrConnection, err)
+	}
+
+	go func() {
+		for msg := range sub.Channel() {
+			handle(msg.Payload)
+		}
+	}()
+
+	return sub, nil
+}
+
+// KeyStats - счетчики сервера Redis: вытесненные по maxmemory и истекшие ключи
+func (c *CacheRedis) KeyStats(ctx context.Context) (evicted, expired int64, err error) {
+	info, err := c.client.InfoMap(ctx, "stats").Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %w", cache.ErrGetData, err)
+	}
+
+	stats := info["Stats"]
+	evicted, _ = strconv.ParseInt(stats["evicted_keys"], 10, 64)
+	expired, _ = strconv.ParseInt(stats["expired_keys"], 10, 64)
+
+	return evicted, expired, nil
+}
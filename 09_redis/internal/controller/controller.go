@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/hahaclassic/databases/09_redis/internal/cache"
 	"github.com/hahaclassic/databases/09_redis/internal/service"
 	tableoutput "github.com/hahaclassic/databases/09_redis/pkg/table"
 	"github.com/jedib0t/go-pretty/table"
@@ -34,6 +35,10 @@ func New(s *service.Service) *Controller {
 			name: "Top 10 most streamed tracks in DB with cache",
 			f:    s.Top10MostStreamedTracks(service.CachedDB, true),
 		},
+		{
+			name: "Top 10 most streamed tracks with two-level cache",
+			f:    s.Top10MostStreamedTracks(service.TwoLevel, true),
+		},
 		{
 			name: "Bench without changes",
 			f:    s.Bench(nil, "basic"),
@@ -52,10 +57,24 @@ func New(s *service.Service) *Controller {
 		},
 	}
 
-	return &Controller{
+	c := &Controller{
 		s:        s,
 		handlers: optionHandlers,
 	}
+
+	c.handlers = append(c.handlers,
+		&optionHandler{name: "Track counts by genre (cached)", f: c.genreCounts},
+		&optionHandler{name: "Artist discography (cached)", f: c.artistDiscography},
+		&optionHandler{name: "User playlists (cached)", f: c.userPlaylists},
+		&optionHandler{name: "Top tracks by genre (leaderboard)", f: c.topTracksByGenre},
+		&optionHandler{name: "Top artists by streams (leaderboard)", f: c.topArtists},
+		&optionHandler{name: "Trending tracks for the last 24 hours", f: c.trending(cache.PeriodDay)},
+		&optionHandler{name: "Trending tracks for the last 7 days", f: c.trending(cache.PeriodWeek)},
+		&optionHandler{name: "Rebuild leaderboards from Postgres", f: c.rebuildLeaderboards},
+		&optionHandler{name: "Check leaderboards against Postgres", f: c.checkLeaderboards},
+	)
+
+	return c
 }
 
 func (c *Controller) Run(ctx context.Context) {
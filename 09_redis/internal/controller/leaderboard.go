@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hahaclassic/databases/09_redis/internal/cache"
+	tableoutput "github.com/hahaclassic/databases/09_redis/pkg/table"
+	"github.com/jedib0t/go-pretty/table"
+)
+
+var ErrInvalidNumber = errors.New("invalid number")
+
+func (c *Controller) topTracksByGenre(ctx context.Context) error {
+	genres, err := c.s.GenreCountsCached(ctx)
+	if err != nil {
+		return err
+	}
+
+	headers := []string{"#", "Genre"}
+	rows := make([][]interface{}, 0, len(genres))
+	for i, g := range genres {
+		rows = append(rows, []interface{}{i + 1, g.Genre})
+	}
+	tableoutput.PrintTable(table.StyleDefault, headers, rows)
+
+	var n int
+	fmt.Print("Enter genre number: ")
+	if _, err := fmt.Scan(&n); err != nil {
+		return err
+	}
+	if n < 1 || n > len(genres) {
+		return ErrInvalidNumber
+	}
+
+	tracks, err := c.s.TopTracksByGenre(ctx, genres[n-1].Genre)
+	if err != nil {
+		return err
+	}
+
+	headers = []string{"#", "Name", "Album", "Streams"}
+	rows = make([][]interface{}, 0, len(tracks))
+	for i, track := range tracks {
+		rows = append(rows, []interface{}{i + 1, track.Name, track.Album, track.StreamCount})
+	}
+	tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+
+	return nil
+}
+
+func (c *Controller) topArtists(ctx context.Context) error {
+	artists, err := c.s.TopArtists(ctx)
+	if err != nil {
+		return err
+	}
+
+	headers := []string{"#", "Artist", "Streams"}
+	rows := make([][]interface{}, 0, len(artists))
+	for i, a := range artists {
+		rows = append(rows, []interface{}{i + 1, a.Name, a.StreamCount})
+	}
+	tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+
+	return nil
+}
+
+func (c *Controller) trending(period cache.Period) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		tracks, err := c.s.Trending(ctx, period)
+		if err != nil {
+			return err
+		}
+
+		headers := []string{"#", "Name", "Genre", "New streams", "Streams"}
+		rows := make([][]interface{}, 0, len(tracks))
+		for i, t := range tracks {
+			rows = append(rows, []interface{}{i + 1, t.Track.Name, t.Track.Genre, t.Streams, t.Track.StreamCount})
+		}
+		tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+
+		return nil
+	}
+}
+
+func (c *Controller) rebuildLeaderboards(ctx context.Context) error {
+	if err := c.s.RebuildLeaderboards(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("Leaderboards rebuilt from Postgres")
+
+	return nil
+}
+
+func (c *Controller) checkLeaderboards(ctx context.Context) error {
+	diffs, err := c.s.CheckLeaderboards(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(diffs) == 0 {
+		fmt.Println("Leaderboards are consistent with Postgres")
+		return nil
+	}
+
+	headers := []string{"Leaderboard", "ID", "Postgres", "Redis"}
+	rows := make([][]interface{}, 0, len(diffs))
+	for _, d := range diffs {
+		rows = append(rows, []interface{}{d.Board, d.ID, d.Expected, d.Actual})
+	}
+	tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	tableoutput "github.com/hahaclassic/databases/09_redis/pkg/table"
+	"github.com/jedib0t/go-pretty/table"
+)
+
+func (c *Controller) genreCounts(ctx context.Context) error {
+	genres, err := c.s.GenreCountsCached(ctx)
+	if err != nil {
+		return err
+	}
+
+	headers := []string{"Genre", "Tracks"}
+	rows := make([][]interface{}, 0, len(genres))
+	for _, g := range genres {
+		rows = append(rows, []interface{}{g.Genre, g.Count})
+	}
+	tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+
+	return nil
+}
+
+func (c *Controller) artistDiscography(ctx context.Context) error {
+	artistID, err := readUUID("Enter artist ID: ")
+	if err != nil {
+		return err
+	}
+
+	artist, err := c.s.ArtistDiscographyCached(ctx, artistID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s (%s, %s, since %d)\n", artist.Name, artist.Genre, artist.Country, artist.DebutYear)
+
+	headers := []string{"Album", "Released", "#", "Track", "Streams"}
+	rows := [][]interface{}{}
+	for _, album := range artist.Albums {
+		for i, track := range album.Tracks {
+			rows = append(rows, []interface{}{album.Title, album.ReleaseDate.Format("2006-01-02"),
+				i + 1, track.Name, track.StreamCount})
+		}
+	}
+	tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+
+	return nil
+}
+
+func (c *Controller) userPlaylists(ctx context.Context) error {
+	userID, err := readUUID("Enter user ID: ")
+	if err != nil {
+		return err
+	}
+
+	playlists, err := c.s.UserPlaylistsCached(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	headers := []string{"Title", "Favorite", "Private", "Tracks"}
+	rows := make([][]interface{}, 0, len(playlists))
+	for _, p := range playlists {
+		rows = append(rows, []interface{}{p.Title, p.IsFavorite, p.Private, p.TrackCount})
+	}
+	tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+
+	return nil
+}
+
+func readUUID(prompt string) (uuid.UUID, error) {
+	var strUUID string
+	fmt.Print(prompt)
+	if _, err := fmt.Scan(&strUUID); err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.Parse(strUUID)
+}
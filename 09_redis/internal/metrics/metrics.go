@@ -0,0 +1,191 @@
+// Package metrics собирает метрики кэша и запросов к Postgres в формате
+// Prometheus и отдает их по HTTP (/metrics)
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "music"
+
+	shutdownTimeout = 5 * time.Second
+	// keyStatsTimeout - сколько ждать INFO от Redis при сборе метрик
+	keyStatsTimeout = time.Second
+)
+
+// Metrics - метрики приложения; реализует cache.Metrics
+type Metrics struct {
+	registry *prometheus.Registry
+
+	cacheRequests *prometheus.CounterVec
+	localRequests *prometheus.CounterVec
+	evictions     *prometheus.CounterVec
+	refreshes     *prometheus.CounterVec
+	fallbacks     *prometheus.CounterVec
+	cacheDuration *prometheus.HistogramVec
+	cacheErrors   *prometheus.CounterVec
+
+	queryDuration *prometheus.HistogramVec
+	queryErrors   *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "cache", Name: "requests_total",
+			Help: "Cache reads by result (hit or miss).",
+		}, []string{"cache", "result"}),
+		localRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "cache", Name: "local_requests_total",
+			Help: "In-memory tier reads by result (hit or miss).",
+		}, []string{"cache", "result"}),
+		evictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "cache", Name: "local_evictions_total",
+			Help: "Values evicted from the in-memory tier by reason (size or expired).",
+		}, []string{"cache", "reason"}),
+		refreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "cache", Name: "refreshes_total",
+			Help: "Background refreshes of stale or soon-to-expire values.",
+		}, []string{"cache"}),
+		fallbacks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "cache", Name: "fallbacks_total",
+			Help: "Reads served from Postgres because Redis was unavailable.",
+		}, []string{"cache"}),
+		cacheDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "cache", Name: "operation_duration_seconds",
+			Help:    "Duration of Redis operations and loads on miss.",
+			Buckets: prometheus.ExponentialBuckets(0.00005, 2, 16),
+		}, []string{"cache", "op"}),
+		cacheErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "cache", Name: "operation_errors_total",
+			Help: "Failed Redis operations and loads on miss.",
+		}, []string{"cache", "op"}),
+
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "storage", Name: "query_duration_seconds",
+			Help:    "Duration of storage.Storage calls.",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+		}, []string{"method"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "storage", Name: "query_errors_total",
+			Help: "Failed storage.Storage calls.",
+		}, []string{"method"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.cacheRequests, m.localRequests, m.evictions, m.refreshes, m.fallbacks, m.cacheDuration, m.cacheErrors,
+		m.queryDuration, m.queryErrors,
+	)
+
+	return m
+}
+
+func (m *Metrics) Hit(cache string)  { m.cacheRequests.WithLabelValues(cache, "hit").Inc() }
+func (m *Metrics) Miss(cache string) { m.cacheRequests.WithLabelValues(cache, "miss").Inc() }
+
+func (m *Metrics) LocalHit(cache string)  { m.localRequests.WithLabelValues(cache, "hit").Inc() }
+func (m *Metrics) LocalMiss(cache string) { m.localRequests.WithLabelValues(cache, "miss").Inc() }
+
+func (m *Metrics) Evict(cache, reason string) { m.evictions.WithLabelValues(cache, reason).Inc() }
+
+func (m *Metrics) Refresh(cache string) { m.refreshes.WithLabelValues(cache).Inc() }
+
+func (m *Metrics) Fallback(cache string) { m.fallbacks.WithLabelValues(cache).Inc() }
+
+func (m *Metrics) Observe(cache, op string, d time.Duration, err error) {
+	m.cacheDuration.WithLabelValues(cache, op).Observe(d.Seconds())
+	if err != nil {
+		m.cacheErrors.WithLabelValues(cache, op).Inc()
+	}
+}
+
+// KeyStatsFunc возвращает счетчики вытесненных и истекших ключей сервера Redis
+type KeyStatsFunc func(ctx context.Context) (evicted, expired int64, err error)
+
+// RegisterKeyStats добавляет счетчики ключей сервера Redis; они читаются при
+// каждом сборе метрик
+func (m *Metrics) RegisterKeyStats(stats KeyStatsFunc) {
+	m.registry.MustRegister(&keyStatsCollector{
+		stats: stats,
+		evicted: prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis", "evicted_keys_total"),
+			"Keys evicted by Redis due to maxmemory.", nil, nil),
+		expired: prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis", "expired_keys_total"),
+			"Keys expired by Redis.", nil, nil),
+	})
+}
+
+type keyStatsCollector struct {
+	stats            KeyStatsFunc
+	evicted, expired *prometheus.Desc
+}
+
+func (c *keyStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.evicted
+	ch <- c.expired
+}
+
+func (c *keyStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyStatsTimeout)
+	defer cancel()
+
+	// без Redis счетчики не отдаются, остальные метрики собираются как обычно
+	evicted, expired, err := c.stats(ctx)
+	if err != nil {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.evicted, prometheus.CounterValue, float64(evicted))
+	ch <- prometheus.MustNewConstMetric(c.expired, prometheus.CounterValue, float64(expired))
+}
+
+// RegisterCircuitBreaker добавляет состояние автомата защиты Redis
+// (0 - замкнут, 1 - разомкнут, 2 - пробные запросы)
+func (m *Metrics) RegisterCircuitBreaker(state func() float64) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace, Subsystem: "redis", Name: "circuit_breaker_state",
+		Help: "Redis circuit breaker state: 0 closed, 1 open, 2 half-open.",
+	}, state))
+}
+
+// Handler отдает метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Serve отдает метрики на addr по пути /metrics до отмены ctx
+func (m *Metrics) Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: shutdownTimeout}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("metrics server shutdown", "err", err)
+		}
+	}()
+
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
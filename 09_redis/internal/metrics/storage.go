@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/09_redis/internal/models"
+	"github.com/hahaclassic/databases/09_redis/internal/storage"
+)
+
+// instrumentedStorage - storage.Storage с замером длительности и ошибок вызовов
+type instrumentedStorage struct {
+	storage.Storage
+	m *Metrics
+}
+
+// Storage оборачивает s: каждый вызов учитывается в метриках storage
+func (m *Metrics) Storage(s storage.Storage) storage.Storage {
+	return &instrumentedStorage{Storage: s, m: m}
+}
+
+func (s *instrumentedStorage) observe(method string, start time.Time, err error) {
+	s.m.queryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		s.m.queryErrors.WithLabelValues(method).Inc()
+	}
+}
+
+func (s *instrumentedStorage) Top10MostStreamedTracks(ctx context.Context) ([]*models.Track, error) {
+	start := time.Now()
+	tracks, err := s.Storage.Top10MostStreamedTracks(ctx)
+	s.observe("top10_most_streamed_tracks", start, err)
+
+	return tracks, err
+}
+
+func (s *instrumentedStorage) AddTrack(ctx context.Context, track *models.Track) error {
+	start := time.Now()
+	err := s.Storage.AddTrack(ctx, track)
+	s.observe("add_track", start, err)
+
+	return err
+}
+
+func (s *instrumentedStorage) Tracks(ctx context.Context) ([]*models.Track, error) {
+	start := time.Now()
+	tracks, err := s.Storage.Tracks(ctx)
+	s.observe("tracks", start, err)
+
+	return tracks, err
+}
+
+func (s *instrumentedStorage) DeleteRandomTrack(ctx context.Context) (*models.Track, error) {
+	start := time.Now()
+	track, err := s.Storage.DeleteRandomTrack(ctx)
+	s.observe("delete_random_track", start, err)
+
+	return track, err
+}
+
+func (s *instrumentedStorage) UpdateRandomTrackStreams(ctx context.Context, increment int) (*models.Track, error) {
+	start := time.Now()
+	track, err := s.Storage.UpdateRandomTrackStreams(ctx, increment)
+	s.observe("update_random_track_streams", start, err)
+
+	return track, err
+}
+
+func (s *instrumentedStorage) CountTracksByGenre(ctx context.Context) ([]*models.GenreCount, error) {
+	start := time.Now()
+	counts, err := s.Storage.CountTracksByGenre(ctx)
+	s.observe("count_tracks_by_genre", start, err)
+
+	return counts, err
+}
+
+func (s *instrumentedStorage) ArtistDiscography(ctx context.Context, artistID uuid.UUID) (*models.Artist, error) {
+	start := time.Now()
+	artist, err := s.Storage.ArtistDiscography(ctx, artistID)
+	s.observe("artist_discography", start, err)
+
+	return artist, err
+}
+
+func (s *instrumentedStorage) UserPlaylists(ctx context.Context, userID uuid.UUID) ([]*models.Playlist, error) {
+	start := time.Now()
+	playlists, err := s.Storage.UserPlaylists(ctx, userID)
+	s.observe("user_playlists", start, err)
+
+	return playlists, err
+}
+
+func (s *instrumentedStorage) TrackArtists(ctx context.Context) ([]*models.TrackArtist, error) {
+	start := time.Now()
+	artists, err := s.Storage.TrackArtists(ctx)
+	s.observe("track_artists", start, err)
+
+	return artists, err
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Artist - исполнитель с дискографией
+type Artist struct {
+	ID        uuid.UUID
+	Name      string
+	Genre     string
+	Country   string
+	DebutYear int
+	Albums    []*Album
+}
+
+type Album struct {
+	ID          uuid.UUID
+	Title       string
+	ReleaseDate time.Time
+	Tracks      []*Track
+}
@@ -0,0 +1,6 @@
+package models
+
+type GenreCount struct {
+	Genre string
+	Count int
+}
@@ -0,0 +1,23 @@
+package models
+
+import "github.com/google/uuid"
+
+// TrackArtist - исполнитель трека из temp_tracks (tracks_by_artists)
+type TrackArtist struct {
+	TrackID  uuid.UUID
+	ArtistID uuid.UUID
+	Name     string
+}
+
+// ArtistStreams - исполнитель и сумма прослушиваний его треков
+type ArtistStreams struct {
+	ID          uuid.UUID
+	Name        string
+	StreamCount int
+}
+
+// TrendingTrack - трек и прирост его прослушиваний за период
+type TrendingTrack struct {
+	Track   *Track
+	Streams int
+}
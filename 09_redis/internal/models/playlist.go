@@ -0,0 +1,13 @@
+package models
+
+import "github.com/google/uuid"
+
+// Playlist - плейлист в библиотеке пользователя
+type Playlist struct {
+	ID          uuid.UUID
+	Title       string
+	Description string
+	Private     bool
+	IsFavorite  bool
+	TrackCount  int
+}
@@ -5,11 +5,32 @@ import (
 )
 
 type Track struct {
-	ID          uuid.UUID `fake:"-"`
-	Name        string    `fake:"{sentence:3}"`
-	Album       string    `fake:"{sentence:2}"`
-	Explicit    bool      `fake:"{bool}"`
-	Duration    int       `fake:"{number:180,300}"`
-	Genre       string    `fake:"-"`
-	StreamCount int       `fake:"{number:0,5000000}"`
+	ID          uuid.UUID `json:"id" fake:"-"`
+	Name        string    `json:"name" fake:"{sentence:3}"`
+	Album       string    `json:"album" fake:"{sentence:2}"`
+	Explicit    bool      `json:"explicit" fake:"{bool}"`
+	Duration    int       `json:"duration" fake:"{number:180,300}"`
+	Genre       string    `json:"genre" fake:"-"`
+	StreamCount int       `json:"stream_count" fake:"{number:0,5000000}"`
+}
+
+// Операции над temp_tracks (TG_OP в триггере)
+const (
+	TrackInsert   = "INSERT"
+	TrackUpdate   = "UPDATE"
+	TrackDelete   = "DELETE"
+	TrackTruncate = "TRUNCATE"
+)
+
+// TrackChange - изменение строки temp_tracks; Old нет у INSERT, New - у DELETE,
+// у TRUNCATE нет обоих
+type TrackChange struct {
+	Op  string `json:"op"`
+	Old *Track `json:"old"`
+	New *Track `json:"new"`
+}
+
+// DiscographyChange - изменение треков, затрагивающее дискографии исполнителей
+type DiscographyChange struct {
+	ArtistIDs []uuid.UUID `json:"artist_ids"`
 }
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hahaclassic/databases/09_redis/internal/cache"
+	"github.com/hahaclassic/databases/09_redis/internal/models"
+)
+
+const (
+	benchNamespace = "bench_top_tracks"
+	// progressInterval - период вывода прогресса замера
+	progressInterval = 10 * time.Second
+)
+
+// benchTarget - источник топа треков и его замеры
+type benchTarget struct {
+	name string
+	get  func(context.Context) ([]*models.Track, error)
+	// stats - счетчики попаданий (nil для Postgres)
+	stats func() cache.Stats
+
+	mu      sync.Mutex
+	samples []benchSample
+	errors  atomic.Int64
+	// statsStart - счетчики на начало замера, measured - за время замера
+	statsStart cache.Stats
+	measured   cache.Stats
+}
+
+// benchSample - задержка запроса и его окончание от начала замера
+type benchSample struct {
+	at      time.Duration
+	latency time.Duration
+}
+
+// Bench сравнивает чтение топа из Postgres и через каждую стратегию кэширования.
+// Стратегии Bench используют свои ключи и на время замера получают изменения
+// вместе с основной стратегией. Частоты, длительность, прогрев и число читателей
+// задаются config.BenchConfig; запросы прогрева не учитываются.
+// Результаты: data/<changeType>_<db|стратегия>.txt (замеры для gnuplot),
+// data/<changeType>_report.<csv|json> и data/<changeType>_latency.svg.
+// В конце сравниваются кодеки значений (benchCodecs).
+func (s *Service) Bench(change func(context.Context) error, changeType string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if s.bench.Format != reportCSV && s.bench.Format != reportJSON {
+			return fmt.Errorf("%w: %q", ErrReportFormat, s.bench.Format)
+		}
+
+		targets := []*benchTarget{{name: "db", get: s.db.Top10MostStreamedTracks}}
+		strategies := make([]topTracksStrategy, 0, len(Strategies))
+		// стратегии Bench закрываются после возврата основных стратегий
+		defer func() {
+			for _, st := range strategies {
+				if err := st.Close(); err != nil {
+					slog.Warn("close bench strategy", "err", err)
+				}
+			}
+		}()
+
+		for _, strategy := range Strategies {
+			st, err := newTopTracksStrategy(strategy, benchNamespace+":"+string(strategy),
+				s.store, s.rankings, s.db, s.cfg, s.metrics)
+			if err != nil {
+				return err
+			}
+
+			strategies = append(strategies, st)
+
+			// значения прошлого запуска могли устареть
+			if err = st.Reset(ctx); err != nil {
+				return err
+			}
+
+			targets = append(targets, &benchTarget{name: string(strategy), get: st.Top, stats: st.Stats})
+		}
+
+		primary := s.activeStrategies()
+		s.setStrategies(append(slices.Clip(primary), strategies...))
+		defer s.setStrategies(primary)
+
+		s.runBench(ctx, targets, change)
+
+		results := make([]*BenchResult, 0, len(targets))
+		for _, t := range targets {
+			if err := writeSamples(fmt.Sprintf("data/%s_%s.txt", changeType, t.name), t.samples); err != nil {
+				return err
+			}
+			results = append(results, t.result(s.bench.Duration))
+		}
+
+		printBenchResults(results)
+
+		if err := s.writeBenchReport(changeType, results); err != nil {
+			return err
+		}
+
+		return s.benchCodecs(ctx)
+	}
+}
+
+// runBench выполняет прогрев и замер: на каждый источник Concurrency читателей
+// с общей частотой ReadRate, изменения - с частотой WriteRate
+func (s *Service) runBench(ctx context.Context, targets []*benchTarget, change func(context.Context) error) {
+	var (
+		wg        sync.WaitGroup
+		recording atomic.Bool
+		begin     time.Time
+	)
+
+	// запросы выполняются с ctx, чтобы остановка не прерывала последние из них
+	done := make(chan struct{})
+
+	for _, t := range targets {
+		ticks, stop := rateTicks(s.bench.ReadRate, done)
+		defer stop()
+
+		for range max(s.bench.Concurrency, 1) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range ticks {
+					start := time.Now()
+					_, err := t.get(ctx)
+					end := time.Now()
+
+					if !recording.Load() {
+						continue
+					}
+					if err != nil {
+						t.errors.Add(1)
+						slog.Error("top10", "target", t.name, "err", err)
+						continue
+					}
+
+					t.mu.Lock()
+					t.samples = append(t.samples, benchSample{at: end.Sub(begin), latency: end.Sub(start)})
+					t.mu.Unlock()
+				}
+			}()
+		}
+	}
+
+	if change != nil && s.bench.WriteRate > 0 {
+		ticks, stop := rateTicks(s.bench.WriteRate, done)
+		defer stop()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range ticks {
+				if err := change(ctx); err != nil {
+					slog.Error("change", "err", err)
+				}
+			}
+		}()
+	}
+
+	log.Println("bench: warmup", s.bench.Warmup)
+	if !sleep(ctx, s.bench.Warmup) {
+		close(done)
+		wg.Wait()
+		return
+	}
+
+	for _, t := range targets {
+		if t.stats != nil {
+			t.statsStart = t.stats()
+		}
+	}
+	begin = time.Now()
+	recording.Store(true)
+
+	progress := time.NewTicker(progressInterval)
+	defer progress.Stop()
+	timer := time.NewTimer(s.bench.Duration)
+	defer timer.Stop()
+
+loop:
+	for {
+		select {
+		case <-progress.C:
+			log.Println("bench", time.Since(begin).Round(time.Second), "/", s.bench.Duration)
+		case <-timer.C:
+			break loop
+		case <-ctx.Done():
+			break loop
+		}
+	}
+
+	recording.Store(false)
+	for _, t := range targets {
+		if t.stats != nil {
+			t.measured = t.stats().Sub(t.statsStart)
+		}
+	}
+
+	close(done)
+	wg.Wait()
+}
+
+// rateTicks отдает разрешения на запрос с частотой rate в секунду
+// (rate <= 0 - без ограничения) до закрытия done
+func rateTicks(rate float64, done <-chan struct{}) (<-chan struct{}, func()) {
+	ticks := make(chan struct{})
+
+	var ticker *time.Ticker
+	var tickerC <-chan time.Time
+	if rate > 0 {
+		ticker = time.NewTicker(time.Duration(float64(time.Second) / rate))
+		tickerC = ticker.C
+	}
+
+	go func() {
+		defer close(ticks)
+		for {
+			if tickerC != nil {
+				select {
+				case <-tickerC:
+				case <-done:
+					return
+				}
+			}
+
+			select {
+			case ticks <- struct{}{}:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return ticks, func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}
+}
+
+// sleep ждет d; false - если ctx отменен раньше
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// writeSamples пишет замеры в формате "elapsed_ms latency_us" для gnuplot
+func writeSamples(path string, samples []benchSample) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	slices.SortFunc(samples, func(a, b benchSample) int {
+		return int(a.at - b.at)
+	})
+
+	for _, sample := range samples {
+		fmt.Fprintln(f, sample.at.Milliseconds(), sample.latency.Microseconds())
+	}
+
+	return nil
+}
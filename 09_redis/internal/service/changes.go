@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/hahaclassic/databases/09_redis/internal/models"
+	"github.com/hahaclassic/databases/09_redis/internal/storage"
+)
+
+// WatchChanges поддерживает кэши в согласованном состоянии по уведомлениям
+// Postgres, так что изменения любых клиентов (NiFi, psql, другие приложения)
+// сразу попадают в кэш. Блокируется до отмены ctx или ошибки подписки.
+func (s *Service) WatchChanges(ctx context.Context, l storage.Listener) error {
+	handlers := map[string]func(context.Context, string){
+		storage.TempTracksChannel: s.tempTracksNotified,
+		storage.TracksChannel:     s.tracksNotified,
+	}
+
+	onListen := func(ctx context.Context) {
+		s.listening.Store(true)
+		// пока подписки не было, изменения могли пройти мимо кэша
+		if err := s.resetAll(ctx); err != nil {
+			slog.Error("reset caches", "err", err)
+		}
+	}
+
+	// пока подписки нет, сервис сам передает свои изменения кэшам
+	onLost := func(context.Context) {
+		s.listening.Store(false)
+	}
+
+	return l.Listen(ctx, handlers, onListen, onLost)
+}
+
+func (s *Service) tempTracksNotified(ctx context.Context, payload string) {
+	change := &models.TrackChange{}
+	if err := json.Unmarshal([]byte(payload), change); err != nil {
+		slog.Error("temp_tracks notification", "payload", payload, "err", err)
+		return
+	}
+
+	if err := s.trackChanged(ctx, change); err != nil {
+		slog.Error("temp_tracks change", "op", change.Op, "err", err)
+	}
+}
+
+func (s *Service) tracksNotified(ctx context.Context, payload string) {
+	change := &models.DiscographyChange{}
+	if err := json.Unmarshal([]byte(payload), change); err != nil {
+		slog.Error("tracks notification", "payload", payload, "err", err)
+		return
+	}
+
+	ids := make([]string, 0, len(change.ArtistIDs))
+	for _, id := range change.ArtistIDs {
+		ids = append(ids, id.String())
+	}
+
+	if err := s.discographies.Delete(ctx, ids...); err != nil {
+		slog.Error("tracks change", "err", err)
+	}
+}
+
+// wrote обрабатывает изменение, сделанное сервисом, если оно не придет уведомлением
+func (s *Service) wrote(ctx context.Context, change *models.TrackChange) error {
+	if s.listening.Load() {
+		return nil
+	}
+
+	return s.trackChanged(ctx, change)
+}
+
+// trackChanged передает изменение temp_tracks стратегиям кэширования топа и рейтингам
+// и сбрасывает количество треков по жанрам, если оно изменилось
+func (s *Service) trackChanged(ctx context.Context, change *models.TrackChange) error {
+	var errs []error
+	notify := func(fn func(topTracksStrategy) error) {
+		for _, st := range s.activeStrategies() {
+			errs = append(errs, fn(st))
+		}
+	}
+
+	genresChanged := true
+
+	switch {
+	case change.Op == models.TrackInsert && change.New != nil:
+		notify(func(st topTracksStrategy) error { return st.TrackAdded(ctx, change.New) })
+
+	case change.Op == models.TrackDelete && change.Old != nil:
+		notify(func(st topTracksStrategy) error { return st.TrackDeleted(ctx, change.Old) })
+
+	case change.Op == models.TrackUpdate && change.Old != nil && change.New != nil:
+		genresChanged = change.Old.Genre != change.New.Genre
+
+		if streamsOnly(change.Old, change.New) {
+			notify(func(st topTracksStrategy) error { return st.StreamsUpdated(ctx, change.New) })
+		} else {
+			notify(func(st topTracksStrategy) error {
+				return errors.Join(st.TrackDeleted(ctx, change.Old), st.TrackAdded(ctx, change.New))
+			})
+		}
+
+	default:
+		// TRUNCATE и неизвестные изменения
+		return s.resetTracks(ctx)
+	}
+
+	if genresChanged {
+		errs = append(errs, s.genreCounts.Delete(ctx, allGenresKey))
+	}
+	errs = append(errs, s.leaderboardsChanged(ctx, change))
+
+	return errors.Join(errs...)
+}
+
+// streamsOnly - изменились ли в треке только прослушивания
+func streamsOnly(old, new *models.Track) bool {
+	t := *old
+	t.StreamCount = new.StreamCount
+
+	return t == *new
+}
+
+// resetTracks сбрасывает все кэши, зависящие от temp_tracks (рейтинги
+// перестраиваются при следующем чтении)
+func (s *Service) resetTracks(ctx context.Context) error {
+	errs := []error{s.genreCounts.Delete(ctx, allGenresKey)}
+	for _, st := range s.activeStrategies() {
+		errs = append(errs, st.Reset(ctx))
+	}
+	if s.leaderboards != nil {
+		errs = append(errs, s.leaderboards.Clear(ctx))
+	}
+
+	return errors.Join(errs...)
+}
+
+// CacheRecovered сбрасывает кэши после недоступности Redis: изменения за это
+// время в них не попали
+func (s *Service) CacheRecovered(ctx context.Context) {
+	if err := s.resetAll(ctx); err != nil {
+		slog.Error("reset caches after redis recovery", "err", err)
+	}
+}
+
+// resetAll сбрасывает все кэши, в которые попадают изменения данных
+func (s *Service) resetAll(ctx context.Context) error {
+	return errors.Join(s.resetTracks(ctx), s.discographies.Clear(ctx), s.playlists.Clear(ctx))
+}
+
+func (s *Service) activeStrategies() []topTracksStrategy {
+	s.strategiesMu.RLock()
+	defer s.strategiesMu.RUnlock()
+
+	return s.strategies
+}
+
+func (s *Service) setStrategies(strategies []topTracksStrategy) {
+	s.strategiesMu.Lock()
+	defer s.strategiesMu.Unlock()
+
+	s.strategies = strategies
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/hahaclassic/databases/09_redis/internal/cache"
+	"github.com/hahaclassic/databases/09_redis/internal/models"
+	tableoutput "github.com/hahaclassic/databases/09_redis/pkg/table"
+	"github.com/jedib0t/go-pretty/table"
+)
+
+const codecRounds = 100
+
+// benchCodecs сравнивает кодеки на топе треков и на всех треках: размер значения
+// в Redis и время кодирования/декодирования. Результаты - data/codecs.txt.
+func (s *Service) benchCodecs(ctx context.Context) error {
+	top, err := s.db.Top10MostStreamedTracks(ctx)
+	if err != nil {
+		return err
+	}
+
+	all, err := s.db.Tracks(ctx)
+	if err != nil {
+		return err
+	}
+
+	payloads := []struct {
+		name   string
+		tracks []*models.Track
+	}{
+		{"top10", top},
+		{"all", all},
+	}
+
+	f, err := os.Create("data/codecs.txt")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintln(f, "# payload codec compressed size_bytes encode_us decode_us")
+
+	headers := []string{"Payload", "Codec", "zstd", "Size, B", "Encode", "Decode"}
+	rows := [][]interface{}{}
+
+	for _, p := range payloads {
+		for _, codec := range cache.Codecs() {
+			for _, compress := range []bool{false, true} {
+				stats, err := cache.MeasureCodec(codec, compress, p.tracks, codecRounds)
+				if err != nil {
+					return fmt.Errorf("%s: %w", codec.Name(), err)
+				}
+
+				fmt.Fprintln(f, p.name, stats.Codec, stats.Compressed, stats.Size,
+					stats.Encode.Microseconds(), stats.Decode.Microseconds())
+				rows = append(rows, []interface{}{p.name, stats.Codec, stats.Compressed, stats.Size,
+					stats.Encode, stats.Decode})
+			}
+		}
+	}
+
+	tableoutput.PrintTable(table.StyleColoredDark, headers, rows)
+
+	return nil
+}
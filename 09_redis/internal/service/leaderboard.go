@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/09_redis/internal/cache"
+	"github.com/hahaclassic/databases/09_redis/internal/models"
+)
+
+const (
+	leaderboardsNamespace = "leaderboards"
+	leaderboardsVersion   = 1
+	// leaderboardLimit - мест в выводимых рейтингах
+	leaderboardLimit = 10
+)
+
+var ErrNoLeaderboards = errors.New("leaderboards are not supported by the cache store")
+
+// LeaderboardDiff - расхождение рейтинга в Redis с Postgres
+type LeaderboardDiff struct {
+	// Board - "genre:<жанр>" или "artists"
+	Board    string
+	ID       uuid.UUID
+	Expected int
+	Actual   int
+}
+
+func (s *Service) TopTracksByGenre(ctx context.Context, genre string) ([]*models.Track, error) {
+	return withLeaderboards(ctx, s, func(ctx context.Context) ([]*models.Track, error) {
+		return s.leaderboards.TopTracksByGenre(ctx, genre, leaderboardLimit)
+	})
+}
+
+func (s *Service) TopArtists(ctx context.Context) ([]*models.ArtistStreams, error) {
+	return withLeaderboards(ctx, s, func(ctx context.Context) ([]*models.ArtistStreams, error) {
+		return s.leaderboards.TopArtists(ctx, leaderboardLimit)
+	})
+}
+
+// Trending - треки с наибольшим приростом прослушиваний за последние сутки или неделю
+func (s *Service) Trending(ctx context.Context, period cache.Period) ([]*models.TrendingTrack, error) {
+	return withLeaderboards(ctx, s, func(ctx context.Context) ([]*models.TrendingTrack, error) {
+		return s.leaderboards.Trending(ctx, period, leaderboardLimit, time.Now())
+	})
+}
+
+// withLeaderboards читает рейтинги, при необходимости построив их по Postgres
+func withLeaderboards[T any](ctx context.Context, s *Service, read func(context.Context) (T, error)) (T, error) {
+	if s.leaderboards == nil {
+		var zero T
+		return zero, ErrNoLeaderboards
+	}
+
+	value, err := read(ctx)
+	if !errors.Is(err, cache.ErrCacheMiss) {
+		return value, err
+	}
+
+	_, err, _ = s.leaderboardsRebuild.Do("", func() (any, error) {
+		return nil, s.RebuildLeaderboards(ctx)
+	})
+	if err != nil {
+		return value, err
+	}
+
+	return read(ctx)
+}
+
+// RebuildLeaderboards строит рейтинги жанров и исполнителей по Postgres
+func (s *Service) RebuildLeaderboards(ctx context.Context) error {
+	if s.leaderboards == nil {
+		return ErrNoLeaderboards
+	}
+
+	return s.leaderboards.Rebuild(ctx, s.leaderboardSource)
+}
+
+// CheckLeaderboards сравнивает рейтинги жанров и исполнителей с Postgres.
+// Изменения во время проверки могут дать ложные расхождения.
+func (s *Service) CheckLeaderboards(ctx context.Context) ([]*LeaderboardDiff, error) {
+	if s.leaderboards == nil {
+		return nil, ErrNoLeaderboards
+	}
+
+	actual, err := s.leaderboards.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks, artists, err := s.leaderboardSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expected := cache.NewLeaderboardSnapshot(tracks, artists)
+
+	var diffs []*LeaderboardDiff
+	genres := slices.Sorted(maps.Keys(expected.Genres))
+	for genre := range actual.Genres {
+		if _, ok := expected.Genres[genre]; !ok {
+			genres = append(genres, genre)
+		}
+	}
+	for _, genre := range genres {
+		diffs = append(diffs, diffScores("genre:"+genre, expected.Genres[genre], actual.Genres[genre])...)
+	}
+
+	return append(diffs, diffScores("artists", expected.Artists, actual.Artists)...), nil
+}
+
+// diffScores - расхождения прослушиваний; отсутствие в рейтинге равно нулю
+func diffScores(board string, expected, actual map[uuid.UUID]int) []*LeaderboardDiff {
+	var diffs []*LeaderboardDiff
+
+	ids := slices.Collect(maps.Keys(expected))
+	for id := range actual {
+		if _, ok := expected[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+
+	for _, id := range ids {
+		if expected[id] != actual[id] {
+			diffs = append(diffs, &LeaderboardDiff{Board: board, ID: id, Expected: expected[id], Actual: actual[id]})
+		}
+	}
+
+	return diffs
+}
+
+func (s *Service) leaderboardSource(ctx context.Context) ([]*models.Track, []*models.TrackArtist, error) {
+	tracks, err := s.db.Tracks(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	artists, err := s.db.TrackArtists(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tracks, artists, nil
+}
+
+// leaderboardsChanged передает изменение temp_tracks рейтингам
+func (s *Service) leaderboardsChanged(ctx context.Context, change *models.TrackChange) error {
+	if s.leaderboards == nil {
+		return nil
+	}
+
+	switch change.Op {
+	case models.TrackInsert:
+		return s.leaderboards.TrackAdded(ctx, change.New)
+	case models.TrackDelete:
+		return s.leaderboards.TrackRemoved(ctx, change.Old)
+	case models.TrackUpdate:
+		if streamsOnly(change.Old, change.New) {
+			return s.leaderboards.StreamsChanged(ctx, change.New, change.Old.StreamCount, time.Now())
+		}
+
+		return errors.Join(s.leaderboards.TrackRemoved(ctx, change.Old), s.leaderboards.TrackAdded(ctx, change.New))
+	default:
+		return fmt.Errorf("unexpected track change %q", change.Op)
+	}
+}
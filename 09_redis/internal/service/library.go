@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/09_redis/internal/models"
+)
+
+// allGenresKey - единственный ключ кэша количества треков по жанрам
+const allGenresKey = "all"
+
+func (s *Service) GenreCountsCached(ctx context.Context) ([]*models.GenreCount, error) {
+	return s.genreCounts.GetOrLoad(ctx, allGenresKey, s.db.CountTracksByGenre)
+}
+
+func (s *Service) ArtistDiscographyCached(ctx context.Context, artistID uuid.UUID) (*models.Artist, error) {
+	return s.discographies.GetOrLoad(ctx, artistID.String(), func(ctx context.Context) (*models.Artist, error) {
+		return s.db.ArtistDiscography(ctx, artistID)
+	})
+}
+
+func (s *Service) UserPlaylistsCached(ctx context.Context, userID uuid.UUID) ([]*models.Playlist, error) {
+	return s.playlists.GetOrLoad(ctx, userID.String(), func(ctx context.Context) ([]*models.Playlist, error) {
+		return s.db.UserPlaylists(ctx, userID)
+	})
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hahaclassic/databases/09_redis/pkg/svgchart"
+	tableoutput "github.com/hahaclassic/databases/09_redis/pkg/table"
+	"github.com/hahaclassic/databases/pkg/report"
+	"github.com/jedib0t/go-pretty/table"
+)
+
+const (
+	reportCSV  = "csv"
+	reportJSON = "json"
+)
+
+var ErrReportFormat = errors.New("unknown bench report format")
+
+// BenchResult - итоги замера одного источника; длительности в JSON - в микросекундах
+type BenchResult struct {
+	Target    string        `json:"target"`
+	Requests  int           `json:"requests"`
+	Errors    int64         `json:"errors"`
+	ReqPerSec float64       `json:"req_per_sec"`
+	Hits      int64         `json:"hits"`
+	Misses    int64         `json:"misses"`
+	HitRatio  float64       `json:"hit_ratio"`
+	Mean      time.Duration `json:"-"`
+	P50       time.Duration `json:"-"`
+	P90       time.Duration `json:"-"`
+	P99       time.Duration `json:"-"`
+	Max       time.Duration `json:"-"`
+}
+
+func (r *BenchResult) MarshalJSON() ([]byte, error) {
+	type result BenchResult
+
+	return json.Marshal(struct {
+		*result
+		MeanUS int64 `json:"mean_us"`
+		P50US  int64 `json:"p50_us"`
+		P90US  int64 `json:"p90_us"`
+		P99US  int64 `json:"p99_us"`
+		MaxUS  int64 `json:"max_us"`
+	}{(*result)(r), r.Mean.Microseconds(), r.P50.Microseconds(), r.P90.Microseconds(),
+		r.P99.Microseconds(), r.Max.Microseconds()})
+}
+
+var reportHeaders = []string{"Target", "Requests", "Errors", "Req/s", "Hits", "Misses", "Hit ratio",
+	"Mean", "P50", "P90", "P99", "Max"}
+
+// result считает итоги по замерам источника
+func (t *benchTarget) result(duration time.Duration) *BenchResult {
+	r := &BenchResult{
+		Target:   t.name,
+		Requests: len(t.samples),
+		Errors:   t.errors.Load(),
+		Hits:     t.measured.Hits,
+		Misses:   t.measured.Misses,
+	}
+
+	if duration > 0 {
+		r.ReqPerSec = float64(r.Requests) / duration.Seconds()
+	}
+	if total := r.Hits + r.Misses; total > 0 {
+		r.HitRatio = float64(r.Hits) / float64(total)
+	}
+
+	latencies := make([]time.Duration, 0, len(t.samples))
+	for _, sample := range t.samples {
+		latencies = append(latencies, sample.latency)
+	}
+
+	l := report.Summarize(latencies)
+	r.Mean, r.P50, r.P90, r.P99, r.Max = l.Mean, l.P50, l.P90, l.P99, l.Max
+
+	return r
+}
+
+func printBenchResults(results []*BenchResult) {
+	rows := make([][]interface{}, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, []interface{}{r.Target, r.Requests, r.Errors, fmt.Sprintf("%.1f", r.ReqPerSec),
+			r.Hits, r.Misses, fmt.Sprintf("%.2f", r.HitRatio), report.Round(r.Mean), report.Round(r.P50),
+			report.Round(r.P90), report.Round(r.P99), report.Round(r.Max)})
+	}
+
+	tableoutput.PrintTable(table.StyleColoredDark, reportHeaders, rows)
+}
+
+// writeBenchReport пишет data/<changeType>_report.<csv|json> и диаграмму
+// задержек data/<changeType>_latency.svg
+func (s *Service) writeBenchReport(changeType string, results []*BenchResult) error {
+	f, err := os.Create(fmt.Sprintf("data/%s_report.%s", changeType, s.bench.Format))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if s.bench.Format == reportJSON {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(results)
+	} else {
+		err = writeBenchCSV(f, results)
+	}
+	if err != nil {
+		return err
+	}
+
+	svg, err := os.Create(fmt.Sprintf("data/%s_latency.svg", changeType))
+	if err != nil {
+		return err
+	}
+	defer svg.Close()
+
+	err = latencyChart(changeType, results).WriteSVG(svg)
+	if errors.Is(err, svgchart.ErrNoData) {
+		slog.Warn("latency chart", "err", err)
+		return nil
+	}
+
+	return err
+}
+
+// writeBenchCSV пишет результаты в CSV; длительности - в микросекундах
+func writeBenchCSV(w io.Writer, results []*BenchResult) error {
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, []string{r.Target, strconv.Itoa(r.Requests), strconv.FormatInt(r.Errors, 10),
+			strconv.FormatFloat(r.ReqPerSec, 'f', 1, 64), strconv.FormatInt(r.Hits, 10),
+			strconv.FormatInt(r.Misses, 10), strconv.FormatFloat(r.HitRatio, 'f', 3, 64),
+			report.Micros(r.Mean), report.Micros(r.P50), report.Micros(r.P90), report.Micros(r.P99),
+			report.Micros(r.Max)})
+	}
+
+	return report.WriteCSV(w, reportHeaders, rows)
+}
+
+// latencyChart сравнивает перцентили задержек Postgres и стратегий кэширования
+func latencyChart(changeType string, results []*BenchResult) *svgchart.Chart {
+	chart := &svgchart.Chart{
+		Title: fmt.Sprintf("Top 10 tracks latency (%s)", changeType),
+		Format: func(v float64) string {
+			return report.Round(time.Duration(v * float64(time.Microsecond))).String()
+		},
+	}
+
+	p50, p90, p99, maxLatency := make([]float64, 0, len(results)), make([]float64, 0, len(results)),
+		make([]float64, 0, len(results)), make([]float64, 0, len(results))
+	for _, r := range results {
+		chart.Groups = append(chart.Groups, r.Target)
+		p50 = append(p50, float64(r.P50.Microseconds()))
+		p90 = append(p90, float64(r.P90.Microseconds()))
+		p99 = append(p99, float64(r.P99.Microseconds()))
+		maxLatency = append(maxLatency, float64(r.Max.Microseconds()))
+	}
+
+	chart.Series = []svgchart.Series{
+		{Name: "p50", Values: p50},
+		{Name: "p90", Values: p90},
+		{Name: "p99", Values: p99},
+		{Name: "max", Values: maxLatency},
+	}
+
+	return chart
+}
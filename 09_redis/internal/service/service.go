@@ -4,25 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
-	"log/slog"
-	"os"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	fake "github.com/brianvoe/gofakeit/v7"
 	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/09_redis/config"
 	"github.com/hahaclassic/databases/09_redis/internal/cache"
 	"github.com/hahaclassic/databases/09_redis/internal/models"
 	"github.com/hahaclassic/databases/09_redis/internal/storage"
 	"github.com/hahaclassic/databases/09_redis/pkg/rndgenre"
 	tableoutput "github.com/hahaclassic/databases/09_redis/pkg/table"
 	"github.com/jedib0t/go-pretty/table"
-)
-
-const (
-	getInterval    = 500 * time.Millisecond
-	changeInterval = 1000 * time.Millisecond
-	limit          = 200
+	"golang.org/x/sync/singleflight"
 )
 
 type Option int
@@ -31,18 +27,122 @@ const (
 	DB = iota
 	Cache
 	CachedDB
+	// TwoLevel - через кэш в памяти процесса и Redis
+	TwoLevel
+)
+
+// Пространства имен и версии кэшей: версию нужно увеличить при изменении
+// структуры кэшируемой модели или формата записи кэша
+const (
+	topTracksKey = "10"
+
+	topTracksNamespace     = "top_tracks"
+	twoLevelNamespace      = "top_tracks_two_level"
+	topTracksVersion       = 3
+	genreCountsNamespace   = "genre_counts"
+	genreCountsVersion     = 3
+	discographiesNamespace = "discographies"
+	discographiesVersion   = 3
+	playlistsNamespace     = "user_playlists"
+	playlistsVersion       = 3
 )
 
 type Service struct {
-	cached cache.Cache
-	db     storage.Storage
+	db storage.Storage
+
+	store    cache.Store
+	rankings cache.RankingStore
+	cfg      *config.RedisConfig
+	bench    *config.BenchConfig
+	metrics  cache.Metrics
+
+	// top - топ треков по стратегии из конфигурации
+	top topTracksStrategy
+	// twoLevel - топ треков через двухуровневый кэш (Option TwoLevel)
+	twoLevel topTracksStrategy
+	// strategies - стратегии, уведомляемые об изменениях temp_tracks (top и стратегии Bench)
+	strategies   []topTracksStrategy
+	strategiesMu sync.RWMutex
+	// listening - изменения temp_tracks приходят через LISTEN/NOTIFY, в том
+	// числе собственные (обрабатывать их при записи не нужно)
+	listening atomic.Bool
+
+	// leaderboards - рейтинги жанров, исполнителей и трендов (nil, если store
+	// не реализует cache.LeaderboardStore)
+	leaderboards        cache.Leaderboards
+	leaderboardsRebuild singleflight.Group
+
+	genreCounts   *cache.Cache[[]*models.GenreCount]
+	discographies *cache.Cache[*models.Artist]
+	playlists     *cache.Cache[[]*models.Playlist]
 }
 
-func New(cache cache.Cache, storage storage.Storage) *Service {
+func New(store cache.Store, rankings cache.RankingStore, storage storage.Storage, cfg *config.RedisConfig,
+	bench *config.BenchConfig, metrics cache.Metrics) (*Service, error) {
+	top, err := newTopTracksStrategy(Strategy(cfg.Strategy), topTracksNamespace, store, rankings, storage, cfg, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	twoLevel, strategies := top, []topTracksStrategy{top}
+	if Strategy(cfg.Strategy) != StrategyTwoLevel {
+		twoLevel, err = newTopTracksStrategy(StrategyTwoLevel, twoLevelNamespace, store, rankings, storage, cfg, metrics)
+		if err != nil {
+			return nil, err
+		}
+		strategies = append(strategies, twoLevel)
+	}
+
+	ttl := cfg.Expiration
+
+	var leaderboards cache.Leaderboards
+	if lb, ok := store.(cache.LeaderboardStore); ok {
+		leaderboards = lb.Leaderboards(fmt.Sprintf("%s:v%d", leaderboardsNamespace, leaderboardsVersion))
+	}
+
 	return &Service{
-		cached: cache,
-		db:     storage,
+		db:         storage,
+		store:      store,
+		rankings:   rankings,
+		cfg:        cfg,
+		bench:      bench,
+		metrics:    metrics,
+		top:        top,
+		twoLevel:   twoLevel,
+		strategies: strategies,
+
+		leaderboards: leaderboards,
+
+		genreCounts: cache.New(store, cache.Options[[]*models.GenreCount]{
+			Namespace: genreCountsNamespace, Version: genreCountsVersion, TTL: ttl,
+			Metrics: metrics,
+		}),
+		discographies: cache.New(store, cache.Options[*models.Artist]{
+			Namespace: discographiesNamespace, Version: discographiesVersion, TTL: ttl,
+			Codec: cache.Gob, Metrics: metrics,
+			// дискографии без альбомов обычно дополняются - храним их меньше
+			KeyTTL: func(_ string, artist *models.Artist) time.Duration {
+				if len(artist.Albums) == 0 {
+					return ttl / 10
+				}
+				return 0
+			},
+		}),
+		playlists: cache.New(store, cache.Options[[]*models.Playlist]{
+			Namespace: playlistsNamespace, Version: playlistsVersion, TTL: ttl,
+			Metrics: metrics,
+		}),
+	}, nil
+}
+
+// Close закрывает подписки кэшей
+func (s *Service) Close() error {
+	errs := []error{s.genreCounts.Close(), s.discographies.Close(), s.playlists.Close()}
+	for _, st := range s.activeStrategies() {
+		errs = append(errs, st.Close())
 	}
+
+	return errors.Join(errs...)
 }
 
 func (s *Service) Top10MostStreamedTracks(option Option, output bool) func(ctx context.Context) error {
@@ -50,10 +150,12 @@ func (s *Service) Top10MostStreamedTracks(option Option, output bool) func(ctx c
 		DB: s.db.Top10MostStreamedTracks,
 
 		Cache: func(ctx context.Context) ([]*models.Track, error) {
-			return s.cached.Get(ctx, cache.Key)
+			return s.top.Peek(ctx)
 		},
 
 		CachedDB: s.Top10TracksCached,
+
+		TwoLevel: s.twoLevel.Top,
 	}
 
 	return func(ctx context.Context) error {
@@ -76,22 +178,7 @@ func (s *Service) Top10MostStreamedTracks(option Option, output bool) func(ctx c
 }
 
 func (s *Service) Top10TracksCached(ctx context.Context) ([]*models.Track, error) {
-	if tracks, err := s.cached.Get(ctx, cache.Key); err == nil {
-		return tracks, nil
-	} else if !errors.Is(err, cache.ErrCacheMiss) {
-		return nil, err
-	}
-
-	tracks, err := s.db.Top10MostStreamedTracks(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	if err = s.cached.Set(ctx, cache.Key, tracks); err != nil {
-		return nil, err
-	}
-
-	return tracks, nil
+	return s.top.Top(ctx)
 }
 
 func (s *Service) AddTrack(ctx context.Context) error {
@@ -115,87 +202,28 @@ func (s *Service) AddTrack(ctx context.Context) error {
 		return err
 	}
 
-	return s.cached.Delete(ctx, cache.Key)
+	return s.wrote(ctx, &models.TrackChange{Op: models.TrackInsert, New: track})
 }
 
 func (s *Service) DeleteRandomTrack(ctx context.Context) error {
-	if err := s.db.DeleteRandomTrack(ctx); err != nil {
+	track, err := s.db.DeleteRandomTrack(ctx)
+	if err != nil {
 		return err
 	}
 
-	return s.cached.Delete(ctx, cache.Key)
+	return s.wrote(ctx, &models.TrackChange{Op: models.TrackDelete, Old: track})
 }
 
 func (s *Service) UpdateRandomTrackStreams(ctx context.Context) error {
-	if err := s.db.UpdateRandomTrackStreams(ctx); err != nil {
+	increment := 1000 + rand.IntN(9000)
+
+	track, err := s.db.UpdateRandomTrackStreams(ctx, increment)
+	if err != nil {
 		return err
 	}
 
-	return s.cached.Delete(ctx, cache.Key)
-}
-
-func (s *Service) Bench(change func(context.Context) error, changeType string) func(context.Context) error {
-	return func(ctx context.Context) error {
-		tickerDB := time.NewTicker(getInterval)
-		tickerCache := time.NewTicker(getInterval)
-		tickerChange := time.NewTicker(changeInterval)
-
-		fileCache, err := os.Create(fmt.Sprintf("data/%s_cache.txt", changeType))
-		if err != nil {
-			return err
-		}
-		defer fileCache.Close()
-
-		fileDB, err := os.Create(fmt.Sprintf("data/%s_db.txt", changeType))
-		if err != nil {
-			return err
-		}
-		defer fileDB.Close()
-
-		i := 0
-		begin := time.Now()
-
-		for i < limit*2 {
-			select {
-			case <-tickerDB.C:
-				i++
-				log.Println("bench db")
-
-				start := time.Now()
-				err := s.Top10MostStreamedTracks(DB, false)(ctx)
-				end := time.Now()
-				if err != nil {
-					slog.Error("top10 db", "err", err)
-				}
-
-				fmt.Fprintln(fileDB, end.Sub(begin).Milliseconds(), end.Sub(start).Microseconds())
-
-			case <-tickerCache.C:
-				i++
-				log.Println("bench cache")
+	old := *track
+	old.StreamCount -= increment
 
-				start := time.Now()
-				err := s.Top10MostStreamedTracks(CachedDB, false)(ctx)
-				end := time.Now()
-				if err != nil {
-					slog.Error("top10 cached", "err", err)
-				}
-
-				fmt.Fprintln(fileCache, end.Sub(begin).Milliseconds(), end.Sub(start).Microseconds())
-
-			case <-tickerChange.C:
-				if change == nil {
-					continue
-				}
-
-				if err := change(ctx); err != nil {
-					slog.Error("change", "err", err)
-				} else {
-					slog.Info("changed data")
-				}
-			}
-		}
-
-		return nil
-	}
+	return s.wrote(ctx, &models.TrackChange{Op: models.TrackUpdate, Old: &old, New: track})
 }
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/hahaclassic/databases/09_redis/config"
+	"github.com/hahaclassic/databases/09_redis/internal/cache"
+	"github.com/hahaclassic/databases/09_redis/internal/models"
+	"github.com/hahaclassic/databases/09_redis/internal/storage"
+	"golang.org/x/sync/singleflight"
+)
+
+// Strategy - способ поддержания кэша топа треков при изменениях temp_tracks
+type Strategy string
+
+const (
+	// StrategyInvalidate - удаление значения после каждой записи
+	StrategyInvalidate Strategy = "invalidate"
+	// StrategyWriteThrough - пересчет и запись топа после каждой записи
+	StrategyWriteThrough Strategy = "write_through"
+	// StrategySortedSet - инкрементальный рейтинг в sorted set (ZADD/ZREM/ZREVRANGE)
+	StrategySortedSet Strategy = "sorted_set"
+	// StrategyRefreshAhead - записи не трогают кэш, значение обновляется в фоне
+	// незадолго до истечения TTL
+	StrategyRefreshAhead Strategy = "refresh_ahead"
+	// StrategyTwoLevel - как invalidate, но с уровнем в памяти процесса перед Redis
+	// (инвалидация между экземплярами через pub/sub)
+	StrategyTwoLevel Strategy = "two_level"
+)
+
+const topTracksLimit = 10
+
+var ErrUnknownStrategy = errors.New("unknown cache strategy")
+
+// Strategies - все стратегии в порядке сравнения в Bench
+var Strategies = []Strategy{
+	StrategyInvalidate, StrategyWriteThrough, StrategySortedSet, StrategyRefreshAhead, StrategyTwoLevel,
+}
+
+type topTracksStrategy interface {
+	// Top возвращает топ треков, при промахе загружая его из Postgres
+	Top(ctx context.Context) ([]*models.Track, error)
+	// Peek возвращает топ только из кэша
+	Peek(ctx context.Context) ([]*models.Track, error)
+	// Reset удаляет кэшированное значение
+	Reset(ctx context.Context) error
+	// Stats - попадания и промахи Top
+	Stats() cache.Stats
+	// Close освобождает подписки стратегии
+	Close() error
+
+	TrackAdded(ctx context.Context, track *models.Track) error
+	TrackDeleted(ctx context.Context, track *models.Track) error
+	// StreamsUpdated получает трек с новым числом прослушиваний
+	StreamsUpdated(ctx context.Context, track *models.Track) error
+}
+
+// newTopTracksStrategy создает стратегию с ключами в пространстве имен namespace
+func newTopTracksStrategy(strategy Strategy, namespace string, store cache.Store, rankings cache.RankingStore,
+	db storage.Storage, cfg *config.RedisConfig, metrics cache.Metrics) (topTracksStrategy, error) {
+	codec, err := cache.CodecByName(cfg.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := cache.Options[[]*models.Track]{
+		Namespace: namespace, Version: topTracksVersion, TTL: cfg.Expiration,
+		Codec: codec, Compress: cfg.Compress,
+		Lock: cache.LockOptions{
+			TTL:  cfg.LockTTL,
+			Wait: cfg.LockWait,
+		},
+		StaleTTL: cfg.StaleTTL,
+		Metrics:  metrics,
+	}
+
+	switch strategy {
+	case StrategyInvalidate:
+		return &invalidateStrategy{cachedTop{cache.New(store, opts), db}}, nil
+	case StrategyWriteThrough:
+		return &writeThroughStrategy{cachedTop{cache.New(store, opts), db}}, nil
+	case StrategyRefreshAhead:
+		opts.RefreshAhead = cfg.RefreshAhead
+		return &refreshAheadStrategy{cachedTop{cache.New(store, opts), db}}, nil
+	case StrategyTwoLevel:
+		opts.Local = &cache.LocalOptions{Size: cfg.LocalSize, TTL: cfg.LocalTTL}
+		return &invalidateStrategy{cachedTop{cache.New(store, opts), db}}, nil
+	case StrategySortedSet:
+		return &sortedSetStrategy{
+			ranking:   rankings.Ranking(fmt.Sprintf("%s:v%d", namespace, topTracksVersion)),
+			namespace: namespace,
+			db:        db,
+			metrics:   metrics,
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStrategy, strategy)
+	}
+}
+
+// cachedTop - топ треков одним значением в cache.Cache
+type cachedTop struct {
+	cache *cache.Cache[[]*models.Track]
+	db    storage.Storage
+}
+
+func (c *cachedTop) Top(ctx context.Context) ([]*models.Track, error) {
+	return c.cache.GetOrLoad(ctx, topTracksKey, c.db.Top10MostStreamedTracks)
+}
+
+func (c *cachedTop) Peek(ctx context.Context) ([]*models.Track, error) {
+	return c.cache.Get(ctx, topTracksKey)
+}
+
+func (c *cachedTop) Reset(ctx context.Context) error {
+	return c.cache.Delete(ctx, topTracksKey)
+}
+
+func (c *cachedTop) Stats() cache.Stats {
+	return c.cache.Stats()
+}
+
+func (c *cachedTop) Close() error {
+	return c.cache.Close()
+}
+
+type invalidateStrategy struct {
+	cachedTop
+}
+
+func (s *invalidateStrategy) TrackAdded(ctx context.Context, _ *models.Track) error {
+	return s.Reset(ctx)
+}
+
+func (s *invalidateStrategy) TrackDeleted(ctx context.Context, _ *models.Track) error {
+	return s.Reset(ctx)
+}
+
+func (s *invalidateStrategy) StreamsUpdated(ctx context.Context, _ *models.Track) error {
+	return s.Reset(ctx)
+}
+
+type writeThroughStrategy struct {
+	cachedTop
+}
+
+func (s *writeThroughStrategy) TrackAdded(ctx context.Context, _ *models.Track) error {
+	return s.recompute(ctx)
+}
+
+func (s *writeThroughStrategy) TrackDeleted(ctx context.Context, _ *models.Track) error {
+	return s.recompute(ctx)
+}
+
+func (s *writeThroughStrategy) StreamsUpdated(ctx context.Context, _ *models.Track) error {
+	return s.recompute(ctx)
+}
+
+func (s *writeThroughStrategy) recompute(ctx context.Context) error {
+	tracks, err := s.db.Top10MostStreamedTracks(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.cache.Set(ctx, topTracksKey, tracks)
+}
+
+// refreshAheadStrategy допускает устаревание топа не дольше TTL
+type refreshAheadStrategy struct {
+	cachedTop
+}
+
+func (s *refreshAheadStrategy) TrackAdded(context.Context, *models.Track) error {
+	return nil
+}
+
+func (s *refreshAheadStrategy) TrackDeleted(context.Context, *models.Track) error {
+	return nil
+}
+
+func (s *refreshAheadStrategy) StreamsUpdated(context.Context, *models.Track) error {
+	return nil
+}
+
+type sortedSetStrategy struct {
+	ranking   cache.Ranking
+	namespace string
+	db        storage.Storage
+	// rebuild объединяет одновременные перестроения рейтинга
+	rebuild singleflight.Group
+
+	hits    atomic.Int64
+	misses  atomic.Int64
+	metrics cache.Metrics
+}
+
+func (s *sortedSetStrategy) Top(ctx context.Context) ([]*models.Track, error) {
+	tracks, err := s.ranking.Top(ctx, topTracksLimit)
+	switch {
+	case err == nil:
+		s.hits.Add(1)
+		if s.metrics != nil {
+			s.metrics.Hit(s.namespace)
+		}
+		return tracks, nil
+
+	case !errors.Is(err, cache.ErrCacheMiss):
+		// Redis недоступен: топ читается из Postgres
+		if s.metrics != nil {
+			s.metrics.Fallback(s.namespace)
+		}
+		return s.db.Top10MostStreamedTracks(ctx)
+	}
+
+	s.misses.Add(1)
+	if s.metrics != nil {
+		s.metrics.Miss(s.namespace)
+	}
+
+	_, err, _ = s.rebuild.Do("", func() (any, error) {
+		return nil, s.ranking.Rebuild(ctx, s.db.Tracks)
+	})
+	if errors.Is(err, cache.ErrConflict) {
+		// треки менялись во время каждой попытки: топ читается из Postgres
+		return s.db.Top10MostStreamedTracks(ctx)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return s.ranking.Top(ctx, topTracksLimit)
+}
+
+func (s *sortedSetStrategy) Peek(ctx context.Context) ([]*models.Track, error) {
+	return s.ranking.Top(ctx, topTracksLimit)
+}
+
+func (s *sortedSetStrategy) Stats() cache.Stats {
+	return cache.Stats{Hits: s.hits.Load(), Misses: s.misses.Load()}
+}
+
+func (s *sortedSetStrategy) Close() error {
+	return nil
+}
+
+func (s *sortedSetStrategy) Reset(ctx context.Context) error {
+	return s.ranking.Clear(ctx)
+}
+
+func (s *sortedSetStrategy) TrackAdded(ctx context.Context, track *models.Track) error {
+	return s.ranking.Add(ctx, track)
+}
+
+func (s *sortedSetStrategy) TrackDeleted(ctx context.Context, track *models.Track) error {
+	return s.ranking.Remove(ctx, track.ID)
+}
+
+func (s *sortedSetStrategy) StreamsUpdated(ctx context.Context, track *models.Track) error {
+	return s.ranking.SetStreams(ctx, track.ID, track.StreamCount)
+}
@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hahaclassic/databases/09_redis/internal/models"
+	"github.com/hahaclassic/databases/09_redis/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// Количество треков по жанрам
+func (s *Storage) CountTracksByGenre(ctx context.Context) ([]*models.GenreCount, error) {
+	query := `
+        select genre, count(*)
+        from temp_tracks
+        group by genre
+        order by count(*) desc`
+
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrCountTracksByGenre, err)
+	}
+
+	genres, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.GenreCount, error) {
+		g := &models.GenreCount{}
+		err := row.Scan(&g.Genre, &g.Count)
+
+		return g, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrCountTracksByGenre, err)
+	}
+
+	return genres, nil
+}
+
+// Дискография исполнителя (основная схема из 01_init): альбомы по дате выхода с треками
+func (s *Storage) ArtistDiscography(ctx context.Context, artistID uuid.UUID) (artist *models.Artist, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%w: %w", storage.ErrArtistDiscography, err)
+		}
+	}()
+
+	artist = &models.Artist{}
+	err = s.db.QueryRow(ctx, `
+        select id, name, genre, country, debut_year
+        from artists
+        where id = $1`, artistID).
+		Scan(&artist.ID, &artist.Name, &artist.Genre, &artist.Country, &artist.DebutYear)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, `
+        select al.id, al.title, al.release_date
+        from albums al
+        join albums_by_artists aa on aa.album_id = al.id
+        where aa.artist_id = $1
+        order by al.release_date, al.title`, artistID)
+	if err != nil {
+		return nil, err
+	}
+
+	artist.Albums, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.Album, error) {
+		a := &models.Album{}
+		err := row.Scan(&a.ID, &a.Title, &a.ReleaseDate)
+
+		return a, err
+	})
+	if err != nil || len(artist.Albums) == 0 {
+		return artist, err
+	}
+
+	albums := make(map[uuid.UUID]*models.Album, len(artist.Albums))
+	albumIDs := make([]uuid.UUID, 0, len(artist.Albums))
+	for _, a := range artist.Albums {
+		albums[a.ID] = a
+		albumIDs = append(albumIDs, a.ID)
+	}
+
+	rows, err = s.db.Query(ctx, `
+        select album_id, id, name, explicit, duration, genre, stream_count
+        from tracks
+        where album_id = any($1)
+        order by album_id, order_in_album`, albumIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var albumID uuid.UUID
+		track := &models.Track{}
+		if err := rows.Scan(&albumID, &track.ID, &track.Name, &track.Explicit,
+			&track.Duration, &track.Genre, &track.StreamCount); err != nil {
+			return nil, err
+		}
+
+		album := albums[albumID]
+		track.Album = album.Title
+		album.Tracks = append(album.Tracks, track)
+	}
+
+	return artist, rows.Err()
+}
+
+// Плейлисты пользователя с количеством треков
+func (s *Storage) UserPlaylists(ctx context.Context, userID uuid.UUID) ([]*models.Playlist, error) {
+	query := `
+        select p.id, p.title, coalesce(p.description, ''), p.private, up.is_favorite,
+            count(pt.track_id)
+        from user_playlists up
+        join playlists p on p.id = up.playlist_id
+        left join playlist_tracks pt on pt.playlist_id = p.id
+        where up.user_id = $1 and p.deleted_at is null
+        group by p.id, up.is_favorite
+        order by up.is_favorite desc, p.title`
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrUserPlaylists, err)
+	}
+
+	playlists, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.Playlist, error) {
+		p := &models.Playlist{}
+		err := row.Scan(&p.ID, &p.Title, &p.Description, &p.Private, &p.IsFavorite, &p.TrackCount)
+
+		return p, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrUserPlaylists, err)
+	}
+
+	return playlists, nil
+}
+
+// Исполнители треков temp_tracks (основная схема из 01_init); добавленные
+// приложением треки исполнителей не имеют
+func (s *Storage) TrackArtists(ctx context.Context) ([]*models.TrackArtist, error) {
+	query := `
+        select tba.track_id, a.id, a.name
+        from temp_tracks t
+        join tracks_by_artists tba on tba.track_id = t.id
+        join artists a on a.id = tba.artist_id`
+
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrTrackArtists, err)
+	}
+
+	artists, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.TrackArtist, error) {
+		a := &models.TrackArtist{}
+		err := row.Scan(&a.TrackID, &a.ArtistID, &a.Name)
+
+		return a, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrTrackArtists, err)
+	}
+
+	return artists, nil
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/hahaclassic/databases/09_redis/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	listenRetryMin = 500 * time.Millisecond
+	listenRetryMax = 30 * time.Second
+)
+
+func (s *Storage) Listen(ctx context.Context, handlers map[string]func(ctx context.Context, payload string),
+	onListen, onLost func(ctx context.Context)) error {
+	var installed bool
+	err := s.db.QueryRow(ctx,
+		"select exists (select 1 from pg_trigger where tgname = 'temp_tracks_notify')").Scan(&installed)
+	if err != nil {
+		return fmt.Errorf("%w: %w", storage.ErrStorageConnection, err)
+	}
+	if !installed {
+		return storage.ErrNoTrigger
+	}
+
+	retry := listenRetryMin
+	for {
+		subscribed, err := s.listen(ctx, handlers, onListen)
+		if subscribed {
+			onLost(ctx)
+			retry = listenRetryMin
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		slog.Warn("LISTEN connection lost", "err", err, "retry", retry)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retry):
+		}
+		retry = min(2*retry, listenRetryMax)
+	}
+}
+
+// listen держит отдельное соединение (не из пула: подписка живет до его
+// закрытия) и возвращает, успела ли подписка состояться
+func (s *Storage) listen(ctx context.Context, handlers map[string]func(ctx context.Context, payload string),
+	onListen func(ctx context.Context)) (bool, error) {
+	conn, err := pgx.ConnectConfig(ctx, s.db.Config().ConnConfig)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	for channel := range handlers {
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return false, err
+		}
+	}
+	onListen(ctx)
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+
+		if handle, ok := handlers[n.Channel]; ok {
+			handle(ctx, n.Payload)
+		}
+	}
+}
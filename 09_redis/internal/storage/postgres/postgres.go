@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
@@ -9,8 +10,8 @@ import (
 	"github.com/hahaclassic/databases/09_redis/config"
 	"github.com/hahaclassic/databases/09_redis/internal/models"
 	"github.com/hahaclassic/databases/09_redis/internal/storage"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"golang.org/x/exp/rand"
 )
 
 type Storage struct {
@@ -88,24 +89,47 @@ func (s *Storage) AddTrack(ctx context.Context, track *models.Track) error {
 	return nil
 }
 
-func (s *Storage) DeleteRandomTrack(ctx context.Context) error {
+func (s *Storage) Tracks(ctx context.Context) ([]*models.Track, error) {
+	query := `
+        select id, name, album, explicit, duration, genre, stream_count
+        from temp_tracks`
+
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrTracks, err)
+	}
+
+	tracks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.Track, error) {
+		return scanTrack(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrTracks, err)
+	}
+
+	return tracks, nil
+}
+
+func (s *Storage) DeleteRandomTrack(ctx context.Context) (*models.Track, error) {
 	query := `
         DELETE FROM temp_tracks
         WHERE id = (
             SELECT id FROM temp_tracks
             ORDER BY RANDOM()
             LIMIT 1
-        )`
+        )
+        RETURNING id, name, album, explicit, duration, genre, stream_count`
 
-	_, err := s.db.Exec(ctx, query)
-	if err != nil {
-		return fmt.Errorf("%w: %w", storage.ErrDeleteRandomTrack, err)
+	track, err := scanTrack(s.db.QueryRow(ctx, query))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %w", storage.ErrDeleteRandomTrack, storage.ErrNoRowsAffected)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrDeleteRandomTrack, err)
 	}
 
-	return nil
+	return track, nil
 }
 
-func (s *Storage) UpdateRandomTrackStreams(ctx context.Context) error {
+func (s *Storage) UpdateRandomTrackStreams(ctx context.Context, increment int) (*models.Track, error) {
 	query := `
         UPDATE temp_tracks
         SET stream_count = stream_count + $1
@@ -114,13 +138,22 @@ func (s *Storage) UpdateRandomTrackStreams(ctx context.Context) error {
             ORDER BY RANDOM()
             LIMIT 1
         )
-    `
+        RETURNING id, name, album, explicit, duration, genre, stream_count`
 
-	randomIncrement := 1000 + rand.Intn(9000)
-	_, err := s.db.Exec(ctx, query, randomIncrement)
-	if err != nil {
-		return fmt.Errorf("%w: %w", storage.ErrUpdateRandomTrackStreams, err)
+	track, err := scanTrack(s.db.QueryRow(ctx, query, increment))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %w", storage.ErrUpdateRandomTrackStreams, storage.ErrNoRowsAffected)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrUpdateRandomTrackStreams, err)
 	}
 
-	return nil
+	return track, nil
+}
+
+func scanTrack(row pgx.Row) (*models.Track, error) {
+	track := &models.Track{}
+	err := row.Scan(&track.ID, &track.Name, &track.Album, &track.Explicit,
+		&track.Duration, &track.Genre, &track.StreamCount)
+
+	return track, err
 }
@@ -4,24 +4,60 @@ import (
 	"context"
 	"errors"
 
+	"github.com/google/uuid"
+
 	"github.com/hahaclassic/databases/09_redis/internal/models"
 )
 
 var (
 	ErrTop10MostStreamedTracks  = errors.New("failed to get top10 most streamed tracks")
+	ErrTracks                   = errors.New("failed to get tracks")
 	ErrAddTracks                = errors.New("failed to add track")
 	ErrDeleteRandomTrack        = errors.New("failed to delete random track")
 	ErrUpdateRandomTrackStreams = errors.New("failed to update random track streams")
+	ErrCountTracksByGenre       = errors.New("failed to count tracks by genre")
+	ErrArtistDiscography        = errors.New("failed to get artist discography")
+	ErrUserPlaylists            = errors.New("failed to get user playlists")
+	ErrTrackArtists             = errors.New("failed to get track artists")
 
 	ErrTableAlreadyExists = errors.New("table already exists")
 	ErrStorageConnection  = errors.New("storage: can't connect to the database")
 	ErrNoRowsAffected     = errors.New("no rows affected")
 	ErrNotFound           = errors.New("not found")
+	ErrNoTrigger          = errors.New("change notification trigger is not installed")
+)
+
+// Каналы LISTEN/NOTIFY (см. sql/queries.sql)
+const (
+	// TempTracksChannel - изменения temp_tracks (models.TrackChange)
+	TempTracksChannel = "temp_tracks_changes"
+	// TracksChannel - изменения tracks (models.DiscographyChange)
+	TracksChannel = "tracks_changes"
 )
 
 type Storage interface {
 	Top10MostStreamedTracks(ctx context.Context) ([]*models.Track, error)
 	AddTrack(ctx context.Context, track *models.Track) error
-	DeleteRandomTrack(ctx context.Context) error
-	UpdateRandomTrackStreams(ctx context.Context) error
+	// Tracks - все треки (для построения кэша)
+	Tracks(ctx context.Context) ([]*models.Track, error)
+	// DeleteRandomTrack удаляет случайный трек и возвращает его
+	DeleteRandomTrack(ctx context.Context) (*models.Track, error)
+	// UpdateRandomTrackStreams увеличивает прослушивания случайного трека на increment
+	// и возвращает трек с новым значением
+	UpdateRandomTrackStreams(ctx context.Context, increment int) (*models.Track, error)
+	CountTracksByGenre(ctx context.Context) ([]*models.GenreCount, error)
+	ArtistDiscography(ctx context.Context, artistID uuid.UUID) (*models.Artist, error)
+	UserPlaylists(ctx context.Context, userID uuid.UUID) ([]*models.Playlist, error)
+	// TrackArtists - исполнители треков temp_tracks (для построения рейтингов)
+	TrackArtists(ctx context.Context) ([]*models.TrackArtist, error)
+}
+
+// Listener получает уведомления об изменениях данных
+type Listener interface {
+	// Listen подписывается на каналы handlers и вызывает обработчик для каждого
+	// уведомления до отмены ctx; при потере соединения переподключается.
+	// onListen вызывается после каждой (пере)подписки: уведомления, пришедшие
+	// без подписки, потеряны; onLost - при потере подписки.
+	Listen(ctx context.Context, handlers map[string]func(ctx context.Context, payload string),
+		onListen, onLost func(ctx context.Context)) error
 }
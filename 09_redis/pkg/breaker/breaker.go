@@ -0,0 +1,159 @@
+// Package breaker - автомат защиты (circuit breaker): после серии ошибок подряд
+// запросы к зависимости не выполняются, пока не пройдет пробный запрос
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int32
+
+const (
+	// Closed - запросы выполняются
+	Closed State = iota
+	// Open - запросы отклоняются до истечения паузы
+	Open
+	// HalfOpen - раз в паузу пропускается пробный запрос
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+type Breaker struct {
+	mu sync.Mutex
+	// threshold - ошибок подряд до размыкания
+	threshold int
+	// cooldown - пауза до пробного запроса
+	cooldown time.Duration
+
+	state    State
+	failures int
+	// nextProbe - когда можно выполнить пробный запрос
+	nextProbe time.Time
+	// generation меняется при смене состояния и каждом пробном запросе
+	generation uint64
+
+	listeners []func(from, to State)
+}
+
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+	}
+}
+
+// OnStateChange добавляет обработчик смены состояния; он вызывается синхронно
+// в горутине запроса, сменившего состояние
+func (b *Breaker) OnStateChange(fn func(from, to State)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.listeners = append(b.listeners, fn)
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// Ticket - разрешение на запрос. Результат запроса учитывается, только если
+// с момента разрешения состояние не менялось: запрос, начатый до размыкания,
+// не замыкает автомат, а замкнуть его может только последний пробный запрос.
+type Ticket uint64
+
+// Allow возвращает ErrOpen, если запрос выполнять нельзя; результат разрешенного
+// запроса сообщается через Success или Failure с полученным Ticket
+func (b *Breaker) Allow() (Ticket, error) {
+	b.mu.Lock()
+
+	if b.state == Closed {
+		t := Ticket(b.generation)
+		b.mu.Unlock()
+		return t, nil
+	}
+
+	now := time.Now()
+	if now.Before(b.nextProbe) {
+		b.mu.Unlock()
+		return 0, ErrOpen
+	}
+
+	// пробные запросы не чаще раза в паузу, даже если результат предыдущего не пришел
+	b.nextProbe = now.Add(b.cooldown)
+	notify := b.setState(HalfOpen)
+	b.generation++
+	t := Ticket(b.generation)
+	b.mu.Unlock()
+
+	notify()
+
+	return t, nil
+}
+
+func (b *Breaker) Success(t Ticket) {
+	b.mu.Lock()
+	if Ticket(b.generation) != t {
+		b.mu.Unlock()
+		return
+	}
+
+	b.failures = 0
+	notify := b.setState(Closed)
+	b.mu.Unlock()
+
+	notify()
+}
+
+func (b *Breaker) Failure(t Ticket) {
+	b.mu.Lock()
+	if Ticket(b.generation) != t {
+		b.mu.Unlock()
+		return
+	}
+
+	b.failures++
+
+	notify := func() {}
+	if b.state == HalfOpen || b.failures >= b.threshold {
+		b.nextProbe = time.Now().Add(b.cooldown)
+		notify = b.setState(Open)
+	}
+	b.mu.Unlock()
+
+	notify()
+}
+
+// setState меняет состояние и возвращает вызов обработчиков (выполняется без блокировки)
+func (b *Breaker) setState(state State) func() {
+	if b.state == state {
+		return func() {}
+	}
+
+	from, listeners := b.state, b.listeners
+	b.state = state
+	b.generation++
+
+	return func() {
+		for _, fn := range listeners {
+			fn(from, state)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Package svgchart рисует сгруппированные горизонтальные столбцы в SVG
+// с логарифмической шкалой (задержки БД и кэша различаются на порядки)
+package svgchart
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+)
+
+const (
+	width       = 960
+	labelWidth  = 160
+	rightMargin = 40
+	topMargin   = 60
+	axisHeight  = 40
+	legendWidth = 110
+	barHeight   = 14
+	groupGap    = 16
+)
+
+var ErrNoData = errors.New("svgchart: no positive values")
+
+var palette = []string{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1"}
+
+// Series - значения одной метрики для каждой группы
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+type Chart struct {
+	Title  string
+	Groups []string
+	Series []Series
+	// Format подписывает значения и деления шкалы
+	Format func(v float64) string
+}
+
+// WriteSVG выводит диаграмму; неположительные значения не рисуются
+func (c *Chart) WriteSVG(w io.Writer) error {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, s := range c.Series {
+		for _, v := range s.Values {
+			if v > 0 {
+				lo, hi = min(lo, v), max(hi, v)
+			}
+		}
+	}
+	if math.IsInf(lo, 1) {
+		return ErrNoData
+	}
+
+	format := c.Format
+	if format == nil {
+		format = func(v float64) string { return fmt.Sprintf("%g", v) }
+	}
+
+	// шкала от декады ниже минимума до декады выше максимума
+	minExp, maxExp := math.Floor(math.Log10(lo)), math.Ceil(math.Log10(hi))
+	if maxExp == minExp {
+		maxExp++
+	}
+
+	plotLeft := float64(labelWidth)
+	plotWidth := float64(width - labelWidth - rightMargin - legendWidth)
+	x := func(v float64) float64 {
+		return plotLeft + (math.Log10(v)-minExp)/(maxExp-minExp)*plotWidth
+	}
+
+	groupHeight := len(c.Series)*barHeight + groupGap
+	plotHeight := len(c.Groups) * groupHeight
+	height := topMargin + plotHeight + axisHeight
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n",
+		width, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	fmt.Fprintf(&b, `<text x="%d" y="30" font-size="16" font-weight="bold">%s</text>`+"\n",
+		labelWidth, html.EscapeString(c.Title))
+
+	// деления шкалы
+	for e := minExp; e <= maxExp; e++ {
+		v := math.Pow(10, e)
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="#ddd"/>`+"\n",
+			x(v), topMargin, x(v), topMargin+plotHeight)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" fill="#555">%s</text>`+"\n",
+			x(v), topMargin+plotHeight+18, html.EscapeString(format(v)))
+	}
+
+	for g, group := range c.Groups {
+		y0 := topMargin + g*groupHeight
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n",
+			labelWidth-8, y0+len(c.Series)*barHeight/2+4, html.EscapeString(group))
+
+		for i, s := range c.Series {
+			if g >= len(s.Values) || s.Values[g] <= 0 {
+				continue
+			}
+
+			v, y := s.Values[g], y0+i*barHeight
+			fmt.Fprintf(&b, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s"/>`+"\n",
+				plotLeft, y, x(v)-plotLeft, barHeight-2, palette[i%len(palette)])
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d" font-size="10" fill="#333">%s</text>`+"\n",
+				x(v)+4, y+barHeight-4, html.EscapeString(format(v)))
+		}
+	}
+
+	// легенда
+	legendX := width - legendWidth
+	for i, s := range c.Series {
+		y := topMargin + i*20
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/>`+"\n",
+			legendX, y, palette[i%len(palette)])
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`+"\n", legendX+18, y+10, html.EscapeString(s.Name))
+	}
+
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
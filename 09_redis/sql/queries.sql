@@ -24,4 +24,57 @@ FROM
 JOIN 
     albums a 
 ON 
-    t.album_id = a.id;
\ No newline at end of file
+    t.album_id = a.id;
+
+-- Уведомления об изменениях temp_tracks для инвалидации кэша (09_redis слушает
+-- канал temp_tracks_changes): {"op": "...", "old": {...}, "new": {...}}
+CREATE OR REPLACE FUNCTION notify_temp_tracks_change() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('temp_tracks_changes', json_build_object(
+        'op', TG_OP,
+        'old', CASE WHEN TG_OP IN ('UPDATE', 'DELETE') THEN row_to_json(OLD) END,
+        'new', CASE WHEN TG_OP IN ('INSERT', 'UPDATE') THEN row_to_json(NEW) END
+    )::text);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER temp_tracks_notify
+AFTER INSERT OR UPDATE OR DELETE ON temp_tracks
+FOR EACH ROW EXECUTE FUNCTION notify_temp_tracks_change();
+
+CREATE OR REPLACE TRIGGER temp_tracks_notify_truncate
+AFTER TRUNCATE ON temp_tracks
+FOR EACH STATEMENT EXECUTE FUNCTION notify_temp_tracks_change();
+
+-- Изменения tracks затрагивают дискографии исполнителей альбома:
+-- канал tracks_changes, {"artist_ids": [...]}
+CREATE OR REPLACE FUNCTION notify_tracks_change() RETURNS trigger AS $$
+DECLARE
+    album_ids UUID[];
+BEGIN
+    album_ids := ARRAY(
+        SELECT DISTINCT a
+        FROM unnest(ARRAY[
+            CASE WHEN TG_OP IN ('UPDATE', 'DELETE') THEN OLD.album_id END,
+            CASE WHEN TG_OP IN ('INSERT', 'UPDATE') THEN NEW.album_id END
+        ]) a
+        WHERE a IS NOT NULL
+    );
+
+    PERFORM pg_notify('tracks_changes', json_build_object(
+        'artist_ids', (
+            SELECT coalesce(json_agg(DISTINCT artist_id), '[]')
+            FROM albums_by_artists
+            WHERE album_id = ANY(album_ids)
+        )
+    )::text);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER tracks_notify
+AFTER INSERT OR UPDATE OR DELETE ON tracks
+FOR EACH ROW EXECUTE FUNCTION notify_tracks_change();
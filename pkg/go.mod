@@ -0,0 +1,3 @@
+module github.com/hahaclassic/databases/pkg
+
+go 1.23.0
@@ -0,0 +1,136 @@
+package pager
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidSortColumn = errors.New("invalid sort column")
+	ErrInvalidCursor     = errors.New("invalid cursor")
+)
+
+// Column - столбец ключа сортировки: SQL-выражение и его значение в записи (для курсора)
+type Column[T any] struct {
+	Expr  string
+	Value func(T) any
+}
+
+// Keyset описывает keyset-пагинацию для запроса: допустимые столбцы сортировки
+// и уникальные столбцы, однозначно упорядочивающие записи с равным ключом.
+// Условие и ORDER BY строятся по Order средствами конкретного драйвера.
+type Keyset[T any] struct {
+	Columns     map[string]Column[T]
+	TieBreakers []Column[T]
+}
+
+// Order - порядок выборки страницы
+type Order struct {
+	Exprs []string
+	// Desc - направление запроса (при выборке назад порядок инвертируется,
+	// а результат затем разворачивается в Page)
+	Desc bool
+	// Cursor - значения ключа граничной записи (nil - без условия)
+	Cursor Cursor
+	// Limit - q.Size+1: лишняя запись показывает, есть ли записи дальше
+	Limit int
+}
+
+// Key - ключ сортировки как строковое значение: (expr1, expr2, ...)
+func (o *Order) Key() string {
+	return "(" + strings.Join(o.Exprs, ", ") + ")"
+}
+
+// Op - оператор сравнения ключа с курсором
+func (o *Order) Op() string {
+	if o.Desc {
+		return "<"
+	}
+
+	return ">"
+}
+
+// By - список ORDER BY
+func (o *Order) By() string {
+	dir := " ASC"
+	if o.Desc {
+		dir = " DESC"
+	}
+
+	return strings.Join(o.Exprs, dir+", ") + dir
+}
+
+func (k *Keyset[T]) columns(q *Query) ([]Column[T], error) {
+	col, ok := k.Columns[q.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSortColumn, q.SortBy)
+	}
+
+	return append([]Column[T]{col}, k.TieBreakers...), nil
+}
+
+// Order проверяет столбец сортировки и курсор и возвращает порядок выборки страницы q
+// (размер страницы по умолчанию записывается в q.Size)
+func (k *Keyset[T]) Order(q *Query) (*Order, error) {
+	cols, err := k.columns(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Size <= 0 {
+		q.Size = DefaultSize
+	}
+	if q.Cursor != nil && len(q.Cursor) != len(cols) {
+		return nil, ErrInvalidCursor
+	}
+
+	order := &Order{Desc: q.Desc != q.Backward, Cursor: q.Cursor, Limit: q.Size + 1}
+	for _, col := range cols {
+		order.Exprs = append(order.Exprs, col.Expr)
+	}
+
+	return order, nil
+}
+
+// Page собирает страницу из выборки, полученной с LIMIT Order.Limit
+func (k *Keyset[T]) Page(q *Query, items []T) (*Page[T], error) {
+	cols, err := k.columns(q)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{}
+	if len(items) > q.Size {
+		page.HasMore = true
+		items = items[:q.Size]
+	}
+	if q.Backward {
+		slices.Reverse(items)
+	}
+	page.Items = items
+
+	if len(items) > 0 {
+		page.First = cursor(cols, items[0])
+		page.Last = cursor(cols, items[len(items)-1])
+	}
+
+	return page, nil
+}
+
+func cursor[T any](cols []Column[T], item T) Cursor {
+	c := make(Cursor, len(cols))
+	for i, col := range cols {
+		c[i] = col.Value(item)
+	}
+
+	return c
+}
+
+var likeReplacer = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike экранирует спецсимволы LIKE, чтобы фильтр искался как обычная подстрока
+func EscapeLike(pattern string) string {
+	return likeReplacer.Replace(pattern)
+}
@@ -0,0 +1,129 @@
+package pager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+)
+
+const DefaultSize = 10
+
+// Cursor - значения ключа сортировки граничной записи страницы
+type Cursor []any
+
+// Query - параметры keyset-пагинации
+type Query struct {
+	SortBy   string
+	Desc     bool
+	Filter   string // подстрока для поиска по текстовым столбцам
+	Size     int
+	Cursor   Cursor // nil - первая страница
+	Backward bool   // true - страница, предшествующая Cursor
+}
+
+type Page[T any] struct {
+	Items []T
+	First Cursor
+	Last  Cursor
+	// HasMore - есть ли записи дальше в направлении выборки
+	HasMore bool
+}
+
+// Pager - интерактивный постраничный просмотр результата с переключением
+// столбца сортировки и фильтром. Первый столбец в SortColumns - по умолчанию.
+type Pager[T any] struct {
+	Headers     []string
+	SortColumns []string
+	Desc        bool
+	Fetch       func(context.Context, *Query) (*Page[T], error)
+	Row         func(T) []interface{}
+	// Print выводит таблицу, ReadLine читает команду пользователя
+	Print    func(headers []string, rows [][]interface{})
+	ReadLine func(prompt string) (string, error)
+}
+
+func (p *Pager[T]) Run(ctx context.Context) {
+	q := &Query{SortBy: p.SortColumns[0], Desc: p.Desc, Size: DefaultSize}
+	pageNum := 1
+
+	for {
+		page, err := p.Fetch(ctx, q)
+		if err != nil {
+			log.Printf("Error getting page: %v", err)
+			return
+		}
+
+		hasPrev, hasNext := q.Cursor != nil, page.HasMore
+		if q.Backward {
+			hasPrev, hasNext = page.HasMore, true
+		}
+
+		if len(page.Items) == 0 {
+			fmt.Println("No results found.")
+		} else {
+			rows := make([][]interface{}, 0, len(page.Items))
+			for _, item := range page.Items {
+				rows = append(rows, p.Row(item))
+			}
+			p.Print(p.Headers, rows)
+		}
+
+		order := "ASC"
+		if q.Desc {
+			order = "DESC"
+		}
+		fmt.Printf("Page %d | sort: %s %s | filter: %q\n", pageNum, q.SortBy, order, q.Filter)
+
+		if !p.command(q, page, hasPrev, hasNext, &pageNum) {
+			return
+		}
+	}
+}
+
+// command читает команды пользователя до первой, требующей новой выборки.
+// Возвращает false, если просмотр завершен.
+func (p *Pager[T]) command(q *Query, page *Page[T], hasPrev, hasNext bool, pageNum *int) bool {
+	for {
+		cmd, err := p.ReadLine("[n]ext, [p]rev, [s]ort column, [r]everse, [f]ilter, [q]uit: ")
+		if err != nil {
+			return false
+		}
+
+		switch cmd {
+		case "n":
+			if !hasNext {
+				fmt.Println("This is the last page.")
+				continue
+			}
+			q.Cursor, q.Backward = page.Last, false
+			*pageNum++
+		case "p":
+			if !hasPrev {
+				fmt.Println("This is the first page.")
+				continue
+			}
+			q.Cursor, q.Backward = page.First, true
+			*pageNum--
+		case "s":
+			i := slices.Index(p.SortColumns, q.SortBy)
+			q.SortBy = p.SortColumns[(i+1)%len(p.SortColumns)]
+			q.Cursor, q.Backward, *pageNum = nil, false, 1
+		case "r":
+			q.Desc = !q.Desc
+			q.Cursor, q.Backward, *pageNum = nil, false, 1
+		case "f":
+			if q.Filter, err = p.ReadLine("Enter filter (empty to reset): "); err != nil {
+				return false
+			}
+			q.Cursor, q.Backward, *pageNum = nil, false, 1
+		case "q":
+			return false
+		default:
+			fmt.Println("Unknown command.")
+			continue
+		}
+
+		return true
+	}
+}
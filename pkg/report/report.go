@@ -0,0 +1,68 @@
+// Package report - сводка задержек замеров и запись отчетов о них
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Latencies - среднее, перцентили (по методу ближайшего ранга) и максимум задержек
+type Latencies struct {
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+// Summarize считает сводку задержек; latencies сортируется на месте
+func Summarize(latencies []time.Duration) Latencies {
+	if len(latencies) == 0 {
+		return Latencies{}
+	}
+
+	slices.Sort(latencies)
+
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+
+	return Latencies{
+		Mean: total / time.Duration(len(latencies)),
+		P50:  Percentile(latencies, 50),
+		P90:  Percentile(latencies, 90),
+		P99:  Percentile(latencies, 99),
+		Max:  latencies[len(latencies)-1],
+	}
+}
+
+// Percentile - перцентиль p отсортированных задержек по методу ближайшего ранга
+func Percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p+99)/100 - 1
+
+	return sorted[max(i, 0)]
+}
+
+// Round округляет задержку до микросекунд для вывода
+func Round(d time.Duration) time.Duration {
+	return d.Round(time.Microsecond)
+}
+
+// Micros - задержка в микросекундах (для CSV и JSON)
+func Micros(d time.Duration) string {
+	return strconv.FormatInt(d.Microseconds(), 10)
+}
+
+// WriteCSV пишет заголовок и строки отчета в CSV
+func WriteCSV(w io.Writer, headers []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+
+	return cw.WriteAll(rows)
+}